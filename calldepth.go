@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"sort"
+	"strings"
+)
+
+// callStep is one function on a call chain
+type callStep struct {
+	node    *CodeNode
+	inCycle bool // Called from the previous step within the same recursive cycle, which adds no depth
+}
+
+// callDepths computes the maximum acyclic call depth of every function, the number of
+// calls on the longest chain it starts. Recursive cycles are collapsed into a single step
+// so the depth stays finite. It also returns the longest chain for each function, where
+// the calls leading through a cycle to the call leaving it are marked as in the cycle.
+func callDepths(nodes map[string]*CodeNode) (map[*CodeNode]int, map[*CodeNode][]callStep) {
+	components := stronglyConnectedComponents(nodes)
+
+	componentOf := make(map[*CodeNode]int)
+	for i, component := range components {
+		for _, node := range component {
+			componentOf[node] = i
+		}
+	}
+
+	// Components come callees first, so every callee component is resolved before its callers
+	depth := make([]int, len(components))
+	exitFrom := make([]*CodeNode, len(components))
+	exitTo := make([]*CodeNode, len(components))
+	for i, component := range components {
+		for _, node := range component {
+			for _, calledNode := range node.Calls {
+				j, isFunction := componentOf[calledNode]
+				if !isFunction || j == i || !isCallable(calledNode) {
+					continue
+				}
+				if depth[j]+1 > depth[i] || (depth[j]+1 == depth[i] && exitTo[i] != nil && calledNode.Key < exitTo[i].Key) {
+					depth[i] = depth[j] + 1
+					exitFrom[i] = node
+					exitTo[i] = calledNode
+				}
+			}
+		}
+	}
+
+	depths := make(map[*CodeNode]int)
+	chains := make(map[*CodeNode][]callStep)
+	for _, component := range components {
+		for _, node := range component {
+			if !isCallable(node) {
+				continue
+			}
+			depths[node] = depth[componentOf[node]]
+
+			// Follow the deepest exit of each component, stepping through cycles on the way
+			chain := []callStep{{node: node}}
+			current := node
+			for {
+				i := componentOf[current]
+				if exitTo[i] == nil {
+					break
+				}
+				for _, step := range pathWithin(current, exitFrom[i], componentOf) {
+					chain = append(chain, callStep{node: step, inCycle: true})
+				}
+				current = exitTo[i]
+				chain = append(chain, callStep{node: current})
+			}
+			chains[node] = chain
+		}
+	}
+
+	return depths, chains
+}
+
+// pathWithin returns the shortest chain of calls from one function to another in the same
+// strongly connected component, without the starting function
+func pathWithin(from, to *CodeNode, componentOf map[*CodeNode]int) []*CodeNode {
+	previous := map[*CodeNode]*CodeNode{from: nil}
+	queue := []*CodeNode{from}
+	for len(queue) > 0 && previous[to] == nil && from != to {
+		current := queue[0]
+		queue = queue[1:]
+		for _, calledNode := range current.Calls {
+			component, isFunction := componentOf[calledNode]
+			if _, seen := previous[calledNode]; seen || !isFunction || component != componentOf[from] {
+				continue
+			}
+			previous[calledNode] = current
+			queue = append(queue, calledNode)
+		}
+	}
+
+	var path []*CodeNode
+	for step := to; step != from && step != nil; step = previous[step] {
+		path = append([]*CodeNode{step}, path...)
+	}
+	return path
+}
+
+// addCallDepthToOutput adds the call depth of entry points and exported functions,
+// and the longest call chains in the repository, to the report
+func (a *Analysis) addCallDepthToOutput(output *strings.Builder) {
+	const (
+		depthLimit = 20
+		chainLimit = 5
+	)
+
+	depths, chains := callDepths(a.Nodes)
+	functions := selectGraphNodes(a.Nodes, "", 0)
+
+	var roots []*CodeNode
+	for _, node := range functions {
+		if isEntryPoint(node) || ast.IsExported(node.Name) {
+			roots = append(roots, node)
+		}
+	}
+	sort.SliceStable(roots, func(i, j int) bool {
+		return depths[roots[i]] > depths[roots[j]]
+	})
+	if len(roots) > depthLimit {
+		roots = roots[:depthLimit]
+	}
+
+	output.WriteString("\n## Call Depth\n\n")
+	if len(roots) > 0 {
+		output.WriteString("| Function | Kind | Location | Max Depth |\n")
+		output.WriteString("|----------|------|------|----------:|\n")
+		for _, node := range roots {
+			kind := "exported"
+			if isEntryPoint(node) {
+				kind = "entry point"
+			}
+			output.WriteString(fmt.Sprintf("| %s | %s | %s | %d |\n", graphLabel(node), kind, a.sourceLink(node), depths[node]))
+		}
+		output.WriteString("\n")
+	}
+
+	// Longest chains, skipping functions whose chain is already part of a longer one
+	sort.SliceStable(functions, func(i, j int) bool {
+		return depths[functions[i]] > depths[functions[j]]
+	})
+	covered := make(map[*CodeNode]bool)
+	var longest [][]callStep
+	for _, node := range functions {
+		if len(longest) == chainLimit || depths[node] == 0 {
+			break
+		}
+		if covered[node] {
+			continue
+		}
+		for _, step := range chains[node] {
+			covered[step.node] = true
+		}
+		longest = append(longest, chains[node])
+	}
+
+	output.WriteString("### Longest Call Chains\n\n")
+	if len(longest) == 0 {
+		output.WriteString("*No internal call chains found.*\n")
+		return
+	}
+	throughCycle := false
+	for i, chain := range longest {
+		var steps strings.Builder
+		for j, step := range chain {
+			if j > 0 && step.inCycle {
+				steps.WriteString(" ⇝ ")
+				throughCycle = true
+			} else if j > 0 {
+				steps.WriteString(" → ")
+			}
+			steps.WriteString("`" + graphLabel(step.node) + "`")
+		}
+		output.WriteString(fmt.Sprintf("%d. %s (depth %d)\n", i+1, steps.String(), depths[chain[0].node]))
+	}
+	if throughCycle {
+		output.WriteString("\n*`⇝` marks calls within a recursive cycle, which add no depth: the depth counts the `→` calls.*\n")
+	}
+}
+
+// isEntryPoint reports whether a node is the main function of a main package
+func isEntryPoint(node *CodeNode) bool {
+	return node.Type == "function" && node.Name == "main" && strings.HasSuffix(packageKeyOf(node), ":main")
+}
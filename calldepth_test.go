@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCallDepthsThroughCycle(t *testing.T) {
+	// b and c call each other through x, and c leaves the cycle to d
+	analysis := analyzeSource(t, map[string]string{"fx.go": `package fx
+
+func a() { b() }
+func b() { x() }
+func x() { c() }
+func c() { b(); d() }
+func d() {}
+`})
+
+	depths, chains := callDepths(analysis.Nodes)
+	a := nodeByKey(t, analysis, ".:fx:a")
+	if depths[a] != 2 {
+		t.Errorf("depth of a = %d, want 2", depths[a])
+	}
+
+	var steps []string
+	for i, step := range chains[a] {
+		if i > 0 {
+			if step.inCycle {
+				steps = append(steps, "⇝")
+			} else {
+				steps = append(steps, "→")
+			}
+			if !containsNode(chains[a][i-1].node.Calls, step.node) {
+				t.Errorf("chain has %s calling %s, which it does not", chains[a][i-1].node.Name, step.node.Name)
+			}
+		}
+		steps = append(steps, step.node.Name)
+	}
+	if got, want := strings.Join(steps, " "), "a → b ⇝ x ⇝ c → d"; got != want {
+		t.Errorf("chain of a = %q, want %q", got, want)
+	}
+	if arrows := strings.Count(strings.Join(steps, " "), "→"); arrows != depths[a] {
+		t.Errorf("chain of a has %d → calls, depth is %d", arrows, depths[a])
+	}
+}
+
+func TestCallDepthsAcyclic(t *testing.T) {
+	analysis := analyzeSource(t, map[string]string{"fx.go": `package fx
+
+func a() { b(); c() }
+func b() { c() }
+func c() {}
+`})
+
+	depths, _ := callDepths(analysis.Nodes)
+	for name, want := range map[string]int{"a": 2, "b": 1, "c": 0} {
+		if got := depths[nodeByKey(t, analysis, ".:fx:"+name)]; got != want {
+			t.Errorf("depth of %s = %d, want %d", name, got, want)
+		}
+	}
+}
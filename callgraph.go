@@ -0,0 +1,458 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// renderFunctionCallGraph renders the function call graph in Mermaid format.
+// The graph is narrowed to the focused package or symbol when one is set, and
+// split into one diagram per package when it has more nodes than allowed.
+func (a *Analysis) renderFunctionCallGraph(output *strings.Builder) {
+	selected := selectGraphNodes(a.Nodes, a.GraphFocus, a.GraphDepth)
+	if len(selected) == 0 {
+		if a.GraphFocus != "" {
+			output.WriteString(fmt.Sprintf("*No functions match the focus `%s`.*\n\n", a.GraphFocus))
+		}
+		return
+	}
+
+	if a.GraphMaxNodes <= 0 || graphWeight(selected) <= a.GraphMaxNodes {
+		output.WriteString("```mermaid\ngraph TD\n")
+		a.renderGraphNodes(output, selected, selected, nil)
+		output.WriteString("```\n")
+		return
+	}
+
+	// Too many nodes for one diagram, so split the graph by package
+	byPackage := make(map[string][]*CodeNode)
+	for _, node := range selected {
+		packageKey := packageKeyOf(node)
+		byPackage[packageKey] = append(byPackage[packageKey], node)
+	}
+
+	packageKeys := make([]string, 0, len(byPackage))
+	for packageKey := range byPackage {
+		packageKeys = append(packageKeys, packageKey)
+	}
+	sort.Strings(packageKeys)
+
+	for _, packageKey := range packageKeys {
+		diagrams, omitted := splitPackageNodes(byPackage[packageKey], a.GraphMaxNodes)
+		for _, diagram := range diagrams {
+			heading := fmt.Sprintf("### Package `%s`", packageKey)
+			if len(diagrams) > 1 {
+				heading += " (" + strings.Join(diagram.files, ", ") + ")"
+			}
+			output.WriteString(heading + "\n\n")
+			output.WriteString("```mermaid\ngraph TD\n")
+			a.renderGraphNodes(output, diagram.nodes, selected, omitted)
+			output.WriteString("```\n\n")
+		}
+		if len(omitted) > 0 {
+			output.WriteString(fmt.Sprintf("*%d functions of `%s` with the lowest PageRank omitted, use `-graph-focus` to narrow the graph.*\n\n", len(omitted), packageKey))
+		}
+	}
+}
+
+// graphDiagram is one diagram of a package split over several diagrams, with the files it covers
+type graphDiagram struct {
+	files []string
+	nodes []*CodeNode
+}
+
+// graphWeight returns the number of Mermaid nodes drawn for functions, each counting
+// once along with the nodes of the calls it makes through func-typed variables
+func graphWeight(functions []*CodeNode) int {
+	weight := 0
+	for _, node := range functions {
+		weight += 1 + len(node.IndirectCalls)
+	}
+	return weight
+}
+
+// splitPackageNodes splits the nodes of a package too large for one diagram by file, packing whole
+// files together up to maxNodes drawn nodes. A single file with more keeps the functions with the highest
+// PageRank and the rest are returned as omitted, so they are left out of every diagram rather than stubbed.
+func splitPackageNodes(packageNodes []*CodeNode, maxNodes int) ([]graphDiagram, map[*CodeNode]bool) {
+	omitted := make(map[*CodeNode]bool)
+	if graphWeight(packageNodes) <= maxNodes {
+		return []graphDiagram{{nodes: packageNodes}}, omitted
+	}
+
+	byFile := make(map[string][]*CodeNode)
+	var files []string
+	for _, node := range packageNodes {
+		file := filepath.Base(node.FilePath)
+		if byFile[file] == nil {
+			files = append(files, file)
+		}
+		byFile[file] = append(byFile[file], node)
+	}
+	sort.Strings(files)
+
+	var diagrams []graphDiagram
+	var current graphDiagram
+	for _, file := range files {
+		fileNodes := byFile[file]
+		if graphWeight(fileNodes) > maxNodes {
+			ranked := append([]*CodeNode{}, fileNodes...)
+			sort.SliceStable(ranked, func(i, j int) bool {
+				return ranked[i].PageRank > ranked[j].PageRank
+			})
+			fileNodes = nil
+			for _, node := range ranked {
+				if len(fileNodes) > 0 && graphWeight(fileNodes)+graphWeight([]*CodeNode{node}) > maxNodes {
+					omitted[node] = true
+					continue
+				}
+				fileNodes = append(fileNodes, node)
+			}
+			sortNodesByKey(fileNodes)
+		}
+
+		if len(current.nodes) > 0 && graphWeight(current.nodes)+graphWeight(fileNodes) > maxNodes {
+			diagrams = append(diagrams, current)
+			current = graphDiagram{}
+		}
+		current.files = append(current.files, file)
+		current.nodes = append(current.nodes, fileNodes...)
+	}
+	if len(current.nodes) > 0 {
+		diagrams = append(diagrams, current)
+	}
+	return diagrams, omitted
+}
+
+// renderGraphNodes writes the node definitions and edges for one Mermaid diagram.
+// Selected nodes drawn in other diagrams are collapsed into one dashed stub node per
+// package, so they add at most one node per package, while omitted nodes are left out
+// along with their edges.
+func (a *Analysis) renderGraphNodes(output *strings.Builder, diagramNodes []*CodeNode, selected []*CodeNode, omitted map[*CodeNode]bool) {
+	inDiagram := make(map[*CodeNode]bool, len(diagramNodes))
+	for _, node := range diagramNodes {
+		inDiagram[node] = true
+	}
+	inSelection := make(map[*CodeNode]bool, len(selected))
+	for _, node := range selected {
+		inSelection[node] = !omitted[node]
+	}
+
+	// Stub nodes by package, with the functions of other diagrams each one stands for
+	stubs := make(map[string]map[*CodeNode]bool)
+	stub := func(node *CodeNode) string {
+		packageKey := packageKeyOf(node)
+		if stubs[packageKey] == nil {
+			stubs[packageKey] = make(map[*CodeNode]bool)
+		}
+		stubs[packageKey][node] = true
+		return mermaidID("stub:" + packageKey)
+	}
+
+	var edges []string
+	drawn := make(map[string]bool)
+	addEdge := func(edge string) {
+		if !drawn[edge] {
+			drawn[edge] = true
+			edges = append(edges, edge)
+		}
+	}
+	hasCycles := false
+	hasIndirect := false
+
+	for _, node := range diagramNodes {
+		class := ""
+		if node.Cycle != 0 {
+			class = ":::cycle"
+			hasCycles = true
+		}
+		output.WriteString(fmt.Sprintf("    %s[\"%s\"]%s\n", mermaidID(node.Key), graphLabel(node), class))
+
+		// Edges for function calls
+		for _, calledNode := range node.Calls {
+			if !inSelection[calledNode] {
+				continue
+			}
+			if inDiagram[calledNode] {
+				addEdge(graphEdge(node, calledNode))
+			} else {
+				addEdge(fmt.Sprintf("    %s --> %s\n", mermaidID(node.Key), stub(calledNode)))
+			}
+		}
+
+		// Callers from other diagrams go through the stubs as well
+		for _, caller := range node.CalledBy {
+			if inSelection[caller] && !inDiagram[caller] {
+				addEdge(fmt.Sprintf("    %s --> %s\n", stub(caller), mermaidID(node.Key)))
+			}
+		}
+
+		// Functions used as values, such as callbacks, are linked with dotted edges
+		for _, referenced := range node.References {
+			if !inSelection[referenced] {
+				continue
+			}
+			if inDiagram[referenced] {
+				addEdge(referenceEdge(node, referenced))
+			} else {
+				addEdge(fmt.Sprintf("    %s -.-> %s\n", mermaidID(node.Key), stub(referenced)))
+			}
+		}
+		for _, referrer := range node.ReferencedBy {
+			if inSelection[referrer] && !inDiagram[referrer] {
+				addEdge(fmt.Sprintf("    %s -.-> %s\n", stub(referrer), mermaidID(node.Key)))
+			}
+		}
+
+		// Calls through func-typed variables point at the called expression
+		for i, callee := range node.IndirectCalls {
+			id := fmt.Sprintf("%s_indirect%d", mermaidID(node.Key), i+1)
+			output.WriteString(fmt.Sprintf("    %s{{\"%s()\"}}:::indirect\n", id, callee))
+			addEdge(fmt.Sprintf("    %s -.->|indirect| %s\n", mermaidID(node.Key), id))
+			hasIndirect = true
+		}
+	}
+
+	stubPackages := make([]string, 0, len(stubs))
+	for packageKey := range stubs {
+		stubPackages = append(stubPackages, packageKey)
+	}
+	sort.Strings(stubPackages)
+
+	for _, packageKey := range stubPackages {
+		label := fmt.Sprintf("%s: %d functions in other diagrams", packageKey, len(stubs[packageKey]))
+		if len(stubs[packageKey]) == 1 {
+			label = fmt.Sprintf("%s: 1 function in another diagram", packageKey)
+		}
+		output.WriteString(fmt.Sprintf("    %s[\"%s\"]:::stub\n", mermaidID("stub:"+packageKey), label))
+	}
+	for _, edge := range edges {
+		output.WriteString(edge)
+	}
+	for _, node := range diagramNodes {
+		if node.Line > 0 {
+			output.WriteString(fmt.Sprintf("    click %s href \"%s\" \"%s\"\n", mermaidID(node.Key), a.sourceURL(node), sourceLocation(node)))
+		}
+	}
+	if len(stubs) > 0 {
+		output.WriteString("    classDef stub stroke-dasharray: 5 5\n")
+	}
+	if hasCycles {
+		output.WriteString("    classDef cycle fill:#fdd,stroke:#c00\n")
+	}
+	if hasIndirect {
+		output.WriteString("    classDef indirect fill:#eee,stroke-dasharray: 2 2\n")
+	}
+
+	// Colour the diagram's nodes by the chosen centrality metric
+	if a.GraphColor != "" {
+		maxValue := 0.0
+		for _, node := range selected {
+			maxValue = max(maxValue, centralityValue(node, a.GraphColor))
+		}
+		for _, node := range diagramNodes {
+			output.WriteString(fmt.Sprintf("    style %s fill:%s\n", mermaidID(node.Key),
+				centralityColor(centralityValue(node, a.GraphColor), maxValue)))
+		}
+	}
+}
+
+// graphEdge returns the Mermaid edge for a call, drawn thick when it is part of a recursive cycle
+// and labelled when the callee is launched as a goroutine
+func graphEdge(caller, callee *CodeNode) string {
+	arrow := "-->"
+	if isCycleEdge(caller, callee) {
+		arrow = "==>"
+	}
+	if containsNode(caller.Goroutines, callee) {
+		arrow += "|go|"
+	}
+	return fmt.Sprintf("    %s %s %s\n", mermaidID(caller.Key), arrow, mermaidID(callee.Key))
+}
+
+// referenceEdge returns the dotted Mermaid edge for a function used as a value without being called
+func referenceEdge(referrer, referenced *CodeNode) string {
+	return fmt.Sprintf("    %s -.-> %s\n", mermaidID(referrer.Key), mermaidID(referenced.Key))
+}
+
+// selectGraphNodes returns the function, method and closure nodes to include in the call graph, sorted by key.
+// Without a focus every function is returned, otherwise the focused nodes and the nodes
+// within depth calls of them, in either direction (0 means no limit).
+func selectGraphNodes(nodes map[string]*CodeNode, focus string, depth int) []*CodeNode {
+	var functions []*CodeNode
+	for _, node := range nodes {
+		if isCallable(node) {
+			functions = append(functions, node)
+		}
+	}
+	sortNodesByKey(functions)
+
+	if focus == "" {
+		return functions
+	}
+
+	// Breadth-first search from the focused nodes along calls and callers
+	distance := make(map[*CodeNode]int)
+	var queue []*CodeNode
+	for _, node := range functions {
+		if matchesFocus(node, focus) {
+			distance[node] = 0
+			queue = append(queue, node)
+		}
+	}
+
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		if depth > 0 && distance[node] >= depth {
+			continue
+		}
+
+		neighbours := append(append([]*CodeNode{}, node.Calls...), node.CalledBy...)
+		neighbours = append(append(neighbours, node.References...), node.ReferencedBy...)
+		for _, next := range neighbours {
+			if _, seen := distance[next]; !seen {
+				distance[next] = distance[node] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	var selected []*CodeNode
+	for _, node := range functions {
+		if _, ok := distance[node]; ok {
+			selected = append(selected, node)
+		}
+	}
+	return selected
+}
+
+// matchesFocus reports whether a node is named by the focus, which may be a node key,
+// a function name, a Receiver.Method pair, a package name or a package directory
+func matchesFocus(node *CodeNode, focus string) bool {
+	if node.Key == focus || node.Name == focus {
+		return true
+	}
+	if node.Receiver != "" && node.Receiver+"."+node.Name == focus {
+		return true
+	}
+
+	packageKey := packageKeyOf(node)
+	if packageKey == focus {
+		return true
+	}
+	separator := strings.LastIndex(packageKey, ":")
+	return packageKey[:separator] == focus || packageKey[separator+1:] == focus
+}
+
+// packageKeyOf returns the "dir:package" part of a node key
+func packageKeyOf(node *CodeNode) string {
+	if i := strings.LastIndex(node.Key, ":"); i >= 0 {
+		return node.Key[:i]
+	}
+	return node.Key
+}
+
+// graphLabel returns the label shown for a function or method in the call graph
+func graphLabel(node *CodeNode) string {
+	if node.Receiver != "" {
+		return fmt.Sprintf("%s.%s", node.Receiver, node.Name)
+	}
+	return node.Name
+}
+
+// mermaidID encodes a node key as a Mermaid node identifier. Letters and digits are kept and
+// every other character, including _, becomes _hex_, so distinct keys never share an identifier.
+func mermaidID(key string) string {
+	var id strings.Builder
+	for _, r := range key {
+		if r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			id.WriteRune(r)
+		} else {
+			id.WriteString(fmt.Sprintf("_%x_", r))
+		}
+	}
+	return id.String()
+}
+
+// sortNodesByKey sorts nodes by their key so the output is stable between runs
+func sortNodesByKey(nodes []*CodeNode) {
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Key < nodes[j].Key
+	})
+}
+
+// renderPackageCallGraph renders the call graph collapsed to packages or directories in Mermaid format.
+// Each edge is labelled with the number of distinct function calls between the two groups.
+func (a *Analysis) renderPackageCallGraph(output *strings.Builder) {
+	selected := selectGraphNodes(a.Nodes, a.GraphFocus, a.GraphDepth)
+	if len(selected) == 0 {
+		return
+	}
+
+	groupOf := func(node *CodeNode) string {
+		packageKey := packageKeyOf(node)
+		if a.GraphLevel == "directory" {
+			return packageKey[:strings.LastIndex(packageKey, ":")]
+		}
+		return packageKey
+	}
+
+	inSelection := make(map[*CodeNode]bool, len(selected))
+	for _, node := range selected {
+		inSelection[node] = true
+	}
+
+	// Count distinct caller and callee pairs between groups
+	groups := make(map[string]bool)
+	edgeCounts := make(map[[2]string]int)
+	for _, node := range selected {
+		from := groupOf(node)
+		groups[from] = true
+		for _, calledNode := range node.Calls {
+			if !inSelection[calledNode] {
+				continue
+			}
+			to := groupOf(calledNode)
+			if from != to {
+				edgeCounts[[2]string{from, to}]++
+			}
+		}
+	}
+
+	groupNames := make([]string, 0, len(groups))
+	for group := range groups {
+		groupNames = append(groupNames, group)
+	}
+	sort.Strings(groupNames)
+
+	edges := make([][2]string, 0, len(edgeCounts))
+	for edge := range edgeCounts {
+		edges = append(edges, edge)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i][0] != edges[j][0] {
+			return edges[i][0] < edges[j][0]
+		}
+		return edges[i][1] < edges[j][1]
+	})
+
+	output.WriteString("```mermaid\ngraph TD\n")
+	for _, group := range groupNames {
+		label := group
+		if a.GraphLevel != "directory" {
+			separator := strings.LastIndex(group, ":")
+			label = fmt.Sprintf("%s (%s)", group[separator+1:], group[:separator])
+		}
+		output.WriteString(fmt.Sprintf("    %s[\"%s\"]\n", mermaidID("group:"+group), label))
+	}
+	for _, edge := range edges {
+		output.WriteString(fmt.Sprintf("    %s -->|%d| %s\n",
+			mermaidID("group:"+edge[0]), edgeCounts[edge], mermaidID("group:"+edge[1])))
+	}
+	output.WriteString("```\n")
+}
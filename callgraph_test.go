@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMermaidIDIsInjective(t *testing.T) {
+	keys := []string{"a-b:pkg:F", "a_b:pkg:F", "a.b:pkg:F", "a/b:pkg:F", "a b:pkg:F", "ab:pkg:F$1", "ab:pkg:F_1"}
+	seen := make(map[string]string)
+	for _, key := range keys {
+		id := mermaidID(key)
+		if other, exists := seen[id]; exists {
+			t.Errorf("mermaidID(%q) = mermaidID(%q) = %q", key, other, id)
+		}
+		seen[id] = key
+	}
+}
+
+func TestSplitPackageNodes(t *testing.T) {
+	var nodes []*CodeNode
+	add := func(file string, count int) {
+		for i := range count {
+			nodes = append(nodes, &CodeNode{
+				Key:      fmt.Sprintf("p:p:%s%d", file, i),
+				FilePath: file + ".go",
+				PageRank: float64(i),
+			})
+		}
+	}
+	add("a", 2)
+	add("b", 2)
+	add("c", 5)
+
+	diagrams, omitted := splitPackageNodes(nodes, 4)
+	if len(diagrams) != 2 {
+		t.Fatalf("got %d diagrams, want 2", len(diagrams))
+	}
+	for _, diagram := range diagrams {
+		if len(diagram.nodes) > 4 {
+			t.Errorf("diagram of %v has %d nodes, more than 4", diagram.files, len(diagram.nodes))
+		}
+		for _, node := range diagram.nodes {
+			if omitted[node] {
+				t.Errorf("omitted node %s drawn in diagram of %v", node.Key, diagram.files)
+			}
+		}
+	}
+
+	// c.go alone is over the limit, so its node with the lowest PageRank is left out
+	if len(omitted) != 1 || !omitted[nodes[4]] {
+		t.Errorf("omitted %v, want only p:p:c0", omitted)
+	}
+}
+
+func TestSplitPackageNodesCountsIndirectCalls(t *testing.T) {
+	nodes := []*CodeNode{
+		{Key: "p:p:a", FilePath: "a.go", PageRank: 2, IndirectCalls: []string{"handler", "next"}},
+		{Key: "p:p:b", FilePath: "a.go", PageRank: 1},
+		{Key: "p:p:c", FilePath: "b.go"},
+	}
+
+	diagrams, omitted := splitPackageNodes(nodes, 3)
+	for _, diagram := range diagrams {
+		if weight := graphWeight(diagram.nodes); weight > 3 {
+			t.Errorf("diagram of %v draws %d nodes, more than 3", diagram.files, weight)
+		}
+	}
+	if len(omitted) != 1 || !omitted[nodes[1]] {
+		t.Errorf("omitted %v, want only p:p:b", omitted)
+	}
+}
+
+func TestRenderGraphNodesCollapsesStubs(t *testing.T) {
+	caller := &CodeNode{Key: "a:a:run", Name: "run", Type: "function"}
+	var callees []*CodeNode
+	for i := range 3 {
+		callee := &CodeNode{Key: fmt.Sprintf("b:b:f%d", i), Name: fmt.Sprintf("f%d", i), Type: "function"}
+		addCall(caller, callee)
+		callees = append(callees, callee)
+	}
+	selected := append([]*CodeNode{caller}, callees...)
+
+	var output strings.Builder
+	(&Analysis{}).renderGraphNodes(&output, []*CodeNode{caller}, selected, nil)
+	if got := strings.Count(output.String(), ":::stub"); got != 1 {
+		t.Errorf("got %d stub nodes, want 1 for package b:b:\n%s", got, output.String())
+	}
+	if !strings.Contains(output.String(), "b:b: 3 functions in other diagrams") {
+		t.Errorf("stub does not count the functions of b:b:\n%s", output.String())
+	}
+	if got := strings.Count(output.String(), " --> "); got != 1 {
+		t.Errorf("got %d edges, want 1 to the stub\n%s", got, output.String())
+	}
+}
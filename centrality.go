@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// computeCentrality calculates fan-in, fan-out, PageRank and betweenness centrality
+// for every function and method in the call graph and stores them on the nodes
+func computeCentrality(nodes map[string]*CodeNode) {
+	functions := selectGraphNodes(nodes, "", 0)
+	if len(functions) == 0 {
+		return
+	}
+
+	isFunction := make(map[*CodeNode]bool, len(functions))
+	for _, node := range functions {
+		isFunction[node] = true
+	}
+
+	// Distinct callees and callers, ignoring self calls and calls to non-functions
+	callees := make(map[*CodeNode][]*CodeNode, len(functions))
+	for _, node := range functions {
+		for _, calledNode := range node.Calls {
+			if isFunction[calledNode] && calledNode != node {
+				callees[node] = append(callees[node], calledNode)
+			}
+		}
+	}
+	for _, node := range functions {
+		node.FanOut = len(callees[node])
+		for _, calledNode := range callees[node] {
+			calledNode.FanIn++
+		}
+	}
+
+	computePageRank(functions, callees)
+	computeBetweenness(functions, callees)
+}
+
+// computePageRank runs the PageRank power iteration over the call graph,
+// where a call passes importance from the caller to the callee
+func computePageRank(functions []*CodeNode, callees map[*CodeNode][]*CodeNode) {
+	const (
+		damping    = 0.85
+		iterations = 100
+		tolerance  = 1e-9
+	)
+
+	n := float64(len(functions))
+	rank := make(map[*CodeNode]float64, len(functions))
+	for _, node := range functions {
+		rank[node] = 1 / n
+	}
+
+	for i := 0; i < iterations; i++ {
+		// Rank of functions that call nothing is spread evenly over the graph
+		dangling := 0.0
+		for _, node := range functions {
+			if len(callees[node]) == 0 {
+				dangling += rank[node]
+			}
+		}
+
+		next := make(map[*CodeNode]float64, len(functions))
+		for _, node := range functions {
+			next[node] = (1-damping)/n + damping*dangling/n
+		}
+		for _, node := range functions {
+			share := rank[node] / float64(len(callees[node]))
+			for _, calledNode := range callees[node] {
+				next[calledNode] += damping * share
+			}
+		}
+
+		delta := 0.0
+		for _, node := range functions {
+			delta += math.Abs(next[node] - rank[node])
+		}
+		rank = next
+		if delta < tolerance {
+			break
+		}
+	}
+
+	for _, node := range functions {
+		node.PageRank = rank[node]
+	}
+}
+
+// computeBetweenness calculates betweenness centrality with Brandes' algorithm,
+// counting how many shortest call paths between other functions pass through each function
+func computeBetweenness(functions []*CodeNode, callees map[*CodeNode][]*CodeNode) {
+	betweenness := make(map[*CodeNode]float64, len(functions))
+
+	for _, source := range functions {
+		var order []*CodeNode
+		predecessors := make(map[*CodeNode][]*CodeNode)
+		paths := map[*CodeNode]float64{source: 1}
+		distance := map[*CodeNode]int{source: 0}
+
+		// Breadth-first search counting shortest paths from the source
+		queue := []*CodeNode{source}
+		for len(queue) > 0 {
+			node := queue[0]
+			queue = queue[1:]
+			order = append(order, node)
+
+			for _, calledNode := range callees[node] {
+				if _, seen := distance[calledNode]; !seen {
+					distance[calledNode] = distance[node] + 1
+					queue = append(queue, calledNode)
+				}
+				if distance[calledNode] == distance[node]+1 {
+					paths[calledNode] += paths[node]
+					predecessors[calledNode] = append(predecessors[calledNode], node)
+				}
+			}
+		}
+
+		// Accumulate dependencies in order of decreasing distance
+		dependency := make(map[*CodeNode]float64)
+		for i := len(order) - 1; i >= 0; i-- {
+			node := order[i]
+			for _, predecessor := range predecessors[node] {
+				dependency[predecessor] += paths[predecessor] / paths[node] * (1 + dependency[node])
+			}
+			if node != source {
+				betweenness[node] += dependency[node]
+			}
+		}
+	}
+
+	for _, node := range functions {
+		node.Betweenness = betweenness[node]
+	}
+}
+
+// centralityValue returns the metric used to colour graph nodes
+func centralityValue(node *CodeNode, metric string) float64 {
+	switch metric {
+	case "pagerank":
+		return node.PageRank
+	case "betweenness":
+		return node.Betweenness
+	case "fan-in":
+		return float64(node.FanIn)
+	case "fan-out":
+		return float64(node.FanOut)
+	}
+	return 0
+}
+
+// centralityColor maps a centrality value onto a colour from pale yellow to dark red
+func centralityColor(value, maxValue float64) string {
+	ratio := 0.0
+	if maxValue > 0 {
+		ratio = value / maxValue
+	}
+
+	blend := func(from, to int) int {
+		return from + int(math.Round(float64(to-from)*ratio))
+	}
+	return fmt.Sprintf("#%02x%02x%02x", blend(0xff, 0xbd), blend(0xf7, 0x00), blend(0xbc, 0x26))
+}
+
+// addCriticalFunctionsToOutput adds the functions ranked by PageRank to the report
+func (a *Analysis) addCriticalFunctionsToOutput(output *strings.Builder) {
+	const limit = 20
+
+	functions := selectGraphNodes(a.Nodes, "", 0)
+	sort.SliceStable(functions, func(i, j int) bool {
+		if functions[i].PageRank != functions[j].PageRank {
+			return functions[i].PageRank > functions[j].PageRank
+		}
+		return functions[i].Betweenness > functions[j].Betweenness
+	})
+	if len(functions) > limit {
+		functions = functions[:limit]
+	}
+
+	output.WriteString("\n## Critical Functions\n\n")
+	output.WriteString("| Rank | Function | Location | Fan-in | Fan-out | PageRank | Betweenness |\n")
+	output.WriteString("|-----:|----------|------|-------:|--------:|---------:|------------:|\n")
+
+	for i, node := range functions {
+		output.WriteString(fmt.Sprintf("| %d | %s | %s | %d | %d | %.4f | %.1f |\n",
+			i+1, graphLabel(node), a.sourceLink(node), node.FanIn, node.FanOut, node.PageRank, node.Betweenness))
+	}
+}
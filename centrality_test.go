@@ -0,0 +1,54 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestComputeCentrality(t *testing.T) {
+	// a and x both call b, which calls c: every shortest path between them goes through b
+	analysis := analyzeSource(t, map[string]string{"fx.go": `package fx
+
+func a() { b() }
+
+func x() { b(); b() }
+
+func b() { c() }
+
+func c() { c() }
+`})
+
+	tests := []struct {
+		name        string
+		fanIn       int
+		fanOut      int
+		betweenness float64
+	}{
+		{"a", 0, 1, 0},
+		{"x", 0, 1, 0},
+		{"b", 2, 1, 2},
+		{"c", 1, 0, 0},
+	}
+	total := 0.0
+	for _, test := range tests {
+		node := nodeByKey(t, analysis, ".:fx:"+test.name)
+		if node.FanIn != test.fanIn || node.FanOut != test.fanOut {
+			t.Errorf("%s fan-in %d and fan-out %d, want %d and %d", test.name, node.FanIn, node.FanOut, test.fanIn, test.fanOut)
+		}
+		if node.Betweenness != test.betweenness {
+			t.Errorf("%s betweenness %v, want %v", test.name, node.Betweenness, test.betweenness)
+		}
+		total += node.PageRank
+	}
+
+	if math.Abs(total-1) > 1e-6 {
+		t.Errorf("PageRank sums to %v, want 1", total)
+	}
+	a, b, c := nodeByKey(t, analysis, ".:fx:a"), nodeByKey(t, analysis, ".:fx:b"), nodeByKey(t, analysis, ".:fx:c")
+	if !(c.PageRank > b.PageRank && b.PageRank > a.PageRank) {
+		t.Errorf("PageRank of a %v, b %v and c %v, want c > b > a", a.PageRank, b.PageRank, c.PageRank)
+	}
+	if x := nodeByKey(t, analysis, ".:fx:x"); x.PageRank != a.PageRank {
+		t.Errorf("PageRank of callers a %v and x %v differ", a.PageRank, x.PageRank)
+	}
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// structFields returns the fields of a struct, one entry per name, with embedded fields named by their type
+func structFields(structType *ast.StructType) []*FieldInfo {
+	var fields []*FieldInfo
+	if structType.Fields == nil {
+		return fields
+	}
+
+	for _, field := range structType.Fields.List {
+		fieldType := types.ExprString(field.Type)
+		tag := ""
+		if field.Tag != nil {
+			tag, _ = strconv.Unquote(field.Tag.Value)
+		}
+
+		if len(field.Names) == 0 {
+			name := embeddedTypeName(field.Type)
+			fields = append(fields, &FieldInfo{
+				Name:     name,
+				Type:     fieldType,
+				Tag:      tag,
+				Exported: ast.IsExported(name),
+				Embedded: true,
+			})
+			continue
+		}
+
+		for _, name := range field.Names {
+			fields = append(fields, &FieldInfo{
+				Name:     name.Name,
+				Type:     fieldType,
+				Tag:      tag,
+				Exported: name.IsExported(),
+			})
+		}
+	}
+
+	return fields
+}
+
+// interfaceMembers returns the embedded types and the method signatures of an interface
+func interfaceMembers(interfaceType *ast.InterfaceType) ([]*FieldInfo, []string) {
+	var embedded []*FieldInfo
+	var methods []string
+	if interfaceType.Methods == nil {
+		return embedded, methods
+	}
+
+	for _, field := range interfaceType.Methods.List {
+		if funcType, ok := field.Type.(*ast.FuncType); ok && len(field.Names) > 0 {
+			signature := strings.TrimPrefix(types.ExprString(funcType), "func")
+			for _, name := range field.Names {
+				methods = append(methods, name.Name+signature)
+			}
+			continue
+		}
+
+		// Embedded interfaces and type set constraints
+		name := embeddedTypeName(field.Type)
+		embedded = append(embedded, &FieldInfo{
+			Name:     name,
+			Type:     types.ExprString(field.Type),
+			Exported: ast.IsExported(name),
+			Embedded: true,
+		})
+	}
+
+	return embedded, methods
+}
+
+// embeddedTypeName returns the name an embedded type is accessed by, e.g. Mutex for *sync.Mutex
+func embeddedTypeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return embeddedTypeName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.IndexExpr:
+		return embeddedTypeName(t.X)
+	case *ast.IndexListExpr:
+		return embeddedTypeName(t.X)
+	}
+	return types.ExprString(expr)
+}
+
+// referencedTypeName returns the named type at the core of a field type, and whether the
+// field holds it by value. *T, []T and map[K]T all refer to T, but not by value.
+func referencedTypeName(typeString string) (string, bool) {
+	byValue := true
+	for {
+		switch {
+		case strings.HasPrefix(typeString, "*"):
+			typeString = typeString[1:]
+		case strings.HasPrefix(typeString, "[]"):
+			typeString = typeString[2:]
+		case strings.HasPrefix(typeString, "map["):
+			_, typeString, _ = splitMapType(typeString)
+		default:
+			if i := strings.Index(typeString, "["); i >= 0 {
+				typeString = typeString[:i] // Drop type arguments
+			}
+			return typeString, byValue
+		}
+		byValue = false
+	}
+}
+
+// splitMapType splits a map type such as map[string][]int into its key and value types
+func splitMapType(typeString string) (string, string, bool) {
+	if !strings.HasPrefix(typeString, "map[") {
+		return "", "", false
+	}
+
+	depth := 0
+	for i, r := range typeString {
+		if r == '[' {
+			depth++
+		} else if r == ']' {
+			depth--
+			if depth == 0 {
+				return typeString[len("map["):i], typeString[i+1:], true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// methodNames returns the names of the methods declared on a type
+func methodNames(typeNode *CodeNode) map[string]bool {
+	names := make(map[string]bool)
+	for _, child := range typeNode.Children {
+		if child.Type == "method" {
+			names[child.Name] = true
+		}
+	}
+	return names
+}
+
+// detectImplementations records on every named type the interfaces of its package, and of
+// other packages in the module, that the type checker finds the type or a pointer to it
+// implements. Empty interfaces, implemented by everything, and generic types are left out.
+func (a *Analysis) detectImplementations(codeRoot *CodeNode) {
+	packages := make(map[string]*types.Package)
+	for _, pkg := range a.Loaded {
+		if pkg.Types != nil {
+			packages[pkg.Dir+":"+pkg.Name] = pkg.Types
+		}
+	}
+
+	type namedNode struct {
+		packageNode, typeNode *CodeNode
+		named                 *types.Named
+	}
+	var interfaces, implementers []namedNode
+	for _, packageNode := range codeRoot.Children {
+		pkg := packages[packageNode.FilePath+":"+packageNode.Name]
+		if pkg == nil {
+			continue
+		}
+		for _, typeNode := range packageNode.Children {
+			if !isTypeNode(typeNode) {
+				continue
+			}
+			typeName, _ := pkg.Scope().Lookup(typeNode.Name).(*types.TypeName)
+			if typeName == nil || typeName.IsAlias() {
+				continue
+			}
+			named, ok := typeName.Type().(*types.Named)
+			if !ok || named.TypeParams().Len() > 0 {
+				continue // Generic types only have a method set once instantiated
+			}
+			if iface, isInterface := named.Underlying().(*types.Interface); !isInterface {
+				implementers = append(implementers, namedNode{packageNode, typeNode, named})
+			} else if iface.NumMethods() > 0 && iface.IsMethodSet() {
+				interfaces = append(interfaces, namedNode{packageNode, typeNode, named})
+			}
+		}
+	}
+
+	for _, implementer := range implementers {
+		for _, iface := range interfaces {
+			underlying := iface.named.Underlying().(*types.Interface)
+			if !types.Implements(implementer.named, underlying) && !types.Implements(types.NewPointer(implementer.named), underlying) {
+				continue
+			}
+			interfaceName := iface.typeNode.Name
+			if iface.packageNode != implementer.packageNode {
+				interfaceName = iface.packageNode.Name + "." + interfaceName
+			}
+			implementer.typeNode.Implements = append(implementer.typeNode.Implements, interfaceName)
+		}
+	}
+}
+
+// mermaidClassType rewrites a Go type so Mermaid class diagrams can display it,
+// using Mermaid's ~T~ notation for type arguments
+func mermaidClassType(typeString string) string {
+	if strings.HasPrefix(typeString, "func") {
+		return "func"
+	}
+	if strings.HasPrefix(typeString, "[]") {
+		return mermaidClassType(typeString[2:]) + "[]"
+	}
+	replacer := strings.NewReplacer("[", "~", "]", "~", " ", "_", "{", "", "}", "")
+	return replacer.Replace(typeString)
+}
+
+// mermaidClassSignature rewrites the types in an interface method signature for Mermaid
+func mermaidClassSignature(signature string) string {
+	// Slices are marked first so their brackets survive the ~T~ rewrite of type arguments
+	signature = sliceTypePattern.ReplaceAllString(signature, "$1@@")
+	replacer := strings.NewReplacer("[", "~", "]", "~", "{", "", "}", "")
+	return strings.ReplaceAll(replacer.Replace(signature), "@@", "[]")
+}
+
+// sliceTypePattern matches slice types like []*strings.Builder, which Mermaid writes as *strings.Builder[]
+var sliceTypePattern = regexp.MustCompile(`\[\]([\w.*]+)`)
+
+// addClassDiagramsToOutput adds a Mermaid class diagram per package showing the fields and
+// methods of its types, and their composition, embedding and implementation relationships
+func (a *Analysis) addClassDiagramsToOutput(output *strings.Builder) {
+	headerWritten := false
+
+	for _, packageNode := range a.CodeRoot.Children {
+		typeNodes := make(map[string]*CodeNode)
+		var typeNames []string
+		for _, child := range packageNode.Children {
+			if child.Type == "struct" || child.Type == "interface" {
+				typeNodes[child.Name] = child
+				typeNames = append(typeNames, child.Name)
+			}
+		}
+		if len(typeNames) == 0 {
+			continue
+		}
+
+		if !headerWritten {
+			output.WriteString("\n## Type Diagrams\n")
+			headerWritten = true
+		}
+		output.WriteString(fmt.Sprintf("\n### Package `%s` (%s)\n\n", packageNode.Name, packageNode.FilePath))
+		output.WriteString("```mermaid\nclassDiagram\n")
+
+		var relations []string
+		external := make(map[string]string) // Types embedded from other packages, by class ID
+		for _, name := range typeNames {
+			typeNode := typeNodes[name]
+			output.WriteString(fmt.Sprintf("    class %s {\n", name))
+			if typeNode.Type == "interface" {
+				output.WriteString("        <<interface>>\n")
+			}
+
+			for _, field := range typeNode.Fields {
+				target, byValue := referencedTypeName(field.Type)
+				if _, local := typeNodes[target]; local && target != name {
+					switch {
+					case field.Embedded:
+						relations = append(relations, fmt.Sprintf("    %s --|> %s : embeds\n", name, target))
+					case byValue:
+						relations = append(relations, fmt.Sprintf("    %s *-- %s : %s\n", name, target, field.Name))
+					default:
+						relations = append(relations, fmt.Sprintf("    %s o-- %s : %s\n", name, target, field.Name))
+					}
+				} else if field.Embedded && !local && !strings.ContainsAny(target, "~| ") {
+					// Embedded types from elsewhere, e.g. sync.Mutex, are drawn as external classes
+					id := mermaidID("embedded:" + target)
+					external[id] = target
+					relations = append(relations, fmt.Sprintf("    %s --|> %s : embeds\n", name, id))
+				}
+				if field.Embedded {
+					continue // Shown as a relationship rather than a member
+				}
+
+				visibility := "-"
+				if field.Exported {
+					visibility = "+"
+				}
+				output.WriteString(fmt.Sprintf("        %s%s %s\n", visibility, mermaidClassType(field.Type), field.Name))
+			}
+
+			for _, method := range typeNode.Methods {
+				output.WriteString(fmt.Sprintf("        +%s\n", mermaidClassSignature(method)))
+			}
+			for _, child := range typeNode.Children {
+				if child.Type != "method" {
+					continue
+				}
+				visibility := "-"
+				if ast.IsExported(child.Name) {
+					visibility = "+"
+				}
+				output.WriteString(fmt.Sprintf("        %s%s()\n", visibility, child.Name))
+			}
+			output.WriteString("    }\n")
+
+			for _, interfaceName := range typeNode.Implements {
+				if _, local := typeNodes[interfaceName]; local {
+					relations = append(relations, fmt.Sprintf("    %s ..|> %s : implements\n", name, interfaceName))
+				}
+			}
+		}
+
+		ids := make([]string, 0, len(external))
+		for id := range external {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			output.WriteString(fmt.Sprintf("    class %s[\"%s\"]\n    <<external>> %s\n", id, external[id], id))
+		}
+
+		sort.Strings(relations)
+		for _, relation := range relations {
+			output.WriteString(relation)
+		}
+		output.WriteString("```\n")
+	}
+}
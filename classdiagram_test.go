@@ -0,0 +1,80 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectImplementations(t *testing.T) {
+	analysis := analyzeSource(t, map[string]string{
+		"store/store.go": `package store
+
+import "io"
+
+type Store interface {
+	Get(key string) ([]byte, error)
+	io.Closer
+}
+
+type Memory struct{}
+
+func (m *Memory) Get(key string) ([]byte, error) { return nil, nil }
+func (m *Memory) Close() error                   { return nil }
+
+// Broken has the methods of Store by name, but Get has the wrong signature
+type Broken struct{}
+
+func (b Broken) Get(key int) []byte { return nil }
+func (b Broken) Close() error       { return nil }
+`,
+		"cache/cache.go": `package cache
+
+type Cache struct{}
+
+func (c Cache) Get(key string) ([]byte, error) { return nil, nil }
+func (c Cache) Close() error                   { return nil }
+`,
+	})
+
+	for key, want := range map[string]string{
+		"store:store:Memory": "Store",
+		"store:store:Broken": "",
+		"cache:cache:Cache":  "store.Store",
+	} {
+		if got := strings.Join(nodeByKey(t, analysis, key).Implements, ", "); got != want {
+			t.Errorf("%s implements %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestClassDiagramShowsExternalEmbeddedTypes(t *testing.T) {
+	analysis := analyzeSource(t, map[string]string{
+		"main.go": `package main
+
+import (
+	"bytes"
+	"sync"
+)
+
+type Buffer struct {
+	sync.Mutex
+	*bytes.Buffer
+	size int
+}
+
+func main() {}
+`,
+	})
+
+	var output strings.Builder
+	analysis.addClassDiagramsToOutput(&output)
+	for _, external := range []string{"sync.Mutex", "bytes.Buffer"} {
+		id := mermaidID("embedded:" + external)
+		if !strings.Contains(output.String(), `class `+id+`["`+external+`"]`) {
+			t.Errorf("no class for %s\n%s", external, output.String())
+		}
+		if !strings.Contains(output.String(), "Buffer --|> "+id+" : embeds") {
+			t.Errorf("Buffer does not embed %s\n%s", external, output.String())
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// isCallable reports whether a node is a function, method or closure
+func isCallable(node *CodeNode) bool {
+	return node.Type == "function" || node.Type == "method" || node.Type == "closure"
+}
+
+// newClosure creates the node of a function literal as a child of the function enclosing it.
+// Closures are numbered in source order within their parent, e.g. main$1, main$2 and main$1$1.
+func (a *Analysis) newClosure(parent *CodeNode, funcLit *ast.FuncLit, relPath string, fset *token.FileSet) *CodeNode {
+	number := 1
+	for _, child := range parent.Children {
+		if child.Type == "closure" {
+			number++
+		}
+	}
+
+	name := fmt.Sprintf("%s$%d", parent.Name, number)
+	closure := &CodeNode{
+		Key:       fmt.Sprintf("%s$%d", parent.Key, number),
+		Name:      name,
+		Type:      "closure",
+		FilePath:  relPath,
+		Receiver:  parent.Receiver,
+		Signature: "func " + name + strings.TrimPrefix(types.ExprString(funcLit.Type), "func"),
+	}
+	setPosition(closure, fset, funcLit)
+
+	parent.Children = append(parent.Children, closure)
+	a.Nodes[closure.Key] = closure
+	return closure
+}
+
+// closureVariable returns the variable a function literal is assigned to, as in
+// visit := func() {...} or var visit = func() {...}, or nil
+func closureVariable(parent ast.Node, funcLit *ast.FuncLit) *ast.Object {
+	var names []ast.Expr
+	var values []ast.Expr
+	switch p := parent.(type) {
+	case *ast.AssignStmt:
+		names, values = p.Lhs, p.Rhs
+	case *ast.ValueSpec:
+		for _, name := range p.Names {
+			names = append(names, name)
+		}
+		values = p.Values
+	default:
+		return nil
+	}
+
+	if len(names) != len(values) {
+		return nil
+	}
+	for i, value := range values {
+		if value == funcLit {
+			if ident, ok := names[i].(*ast.Ident); ok {
+				return ident.Obj
+			}
+		}
+	}
+	return nil
+}
+
+// addCall records a call edge between two nodes once
+func addCall(caller, callee *CodeNode) {
+	if !functionCallExists(caller, callee) {
+		caller.Calls = append(caller.Calls, callee)
+		callee.CalledBy = append(callee.CalledBy, caller)
+	}
+}
+
+// addReference records that a function uses another as a value without calling it,
+// such as a callback passed to http.HandleFunc
+func addReference(referrer, referenced *CodeNode) {
+	if referrer != referenced && !containsNode(referrer.References, referenced) {
+		referrer.References = append(referrer.References, referenced)
+		referenced.ReferencedBy = append(referenced.ReferencedBy, referrer)
+	}
+}
+
+// resolveFunctionValue returns the function or method an identifier or selector refers to, as
+// resolved by the type checker: a function of the package, pkg.Function, a method value such as
+// h.serve, or a method expression such as Server.Run. It returns nil for anything else.
+func (a *Analysis) resolveFunctionValue(info *types.Info, expr ast.Expr) *CodeNode {
+	function, ok := info.Uses[calleeIdent(ast.Unparen(expr))].(*types.Func)
+	if !ok {
+		return nil
+	}
+	if node, exists := a.Nodes[a.objectKey(function)]; exists && isCallable(node) {
+		return node
+	}
+	return nil
+}
+
+// indirectCallee returns the expression called when a call goes through a func-typed variable,
+// parameter or struct field, whose target cannot be known statically, or "" for other calls
+func indirectCallee(info *types.Info, call *ast.CallExpr) string {
+	if _, isVar := info.Uses[calleeIdent(ast.Unparen(call.Fun))].(*types.Var); isVar {
+		return types.ExprString(call.Fun)
+	}
+	return ""
+}
@@ -0,0 +1,49 @@
+package main
+
+import "testing"
+
+func TestClosureNumbering(t *testing.T) {
+	analysis := analyzeSource(t, map[string]string{"fx.go": `package fx
+
+func run() {
+	visit := func() {
+		_ = func() {}
+		_ = func() {}
+	}
+	visit()
+	go func() {}()
+}
+
+func (s *Server) Start() {
+	defer func() {}()
+}
+
+type Server struct{}
+`})
+
+	tests := []struct {
+		key    string
+		parent string
+	}{
+		{".:fx:run$1", ".:fx:run"},
+		{".:fx:run$1$1", ".:fx:run$1"},
+		{".:fx:run$1$2", ".:fx:run$1"},
+		{".:fx:run$2", ".:fx:run"},
+		{".:fx:Server.Start$1", ".:fx:Server.Start"},
+	}
+	for _, test := range tests {
+		closure := nodeByKey(t, analysis, test.key)
+		if closure.Type != "closure" {
+			t.Errorf("%s is a %s", test.key, closure.Type)
+		}
+		if !containsNode(nodeByKey(t, analysis, test.parent).Children, closure) {
+			t.Errorf("%s is not a child of %s", test.key, test.parent)
+		}
+	}
+	if _, exists := analysis.Nodes[".:fx:run$3"]; exists {
+		t.Error("run has a third closure")
+	}
+	if visit := nodeByKey(t, analysis, ".:fx:run$1"); !containsNode(nodeByKey(t, analysis, ".:fx:run").Calls, visit) {
+		t.Error("run does not call the closure assigned to visit")
+	}
+}
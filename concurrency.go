@@ -0,0 +1,232 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// syncMethods lists the methods of the synchronisation types reported in the concurrency map
+var syncMethods = map[string]map[string]bool{
+	"sync.Mutex":     {"Lock": true, "Unlock": true, "TryLock": true},
+	"sync.RWMutex":   {"Lock": true, "Unlock": true, "TryLock": true, "RLock": true, "RUnlock": true, "TryRLock": true},
+	"sync.WaitGroup": {"Add": true, "Done": true, "Wait": true, "Go": true},
+	"errgroup.Group": {"Go": true, "TryGo": true, "Wait": true, "SetLimit": true},
+}
+
+// isMutexType reports whether a type as written is a sync.Mutex or sync.RWMutex, or a pointer to one
+func isMutexType(typeString string) bool {
+	typeString = strings.TrimPrefix(typeString, "*")
+	return typeString == "sync.Mutex" || typeString == "sync.RWMutex"
+}
+
+// recordGoroutine records a go statement and, when the launched function is known,
+// a goroutine edge from the function launching it
+func recordGoroutine(site findingSite, launcher *CodeNode, call *ast.CallExpr, launched *CodeNode) {
+	detail := types.ExprString(call.Fun)
+	if launched != nil && isCallable(launched) {
+		detail = graphLabel(launched)
+		if !containsNode(launcher.Goroutines, launched) {
+			launcher.Goroutines = append(launcher.Goroutines, launched)
+		}
+	}
+	site.record("concurrency", "go", detail, "", launcher, call.Pos())
+}
+
+// recordConcurrency records channel operations and the use of mutexes, WaitGroups and errgroups
+func (a *Analysis) recordConcurrency(n ast.Node, site findingSite, function *CodeNode, info *types.Info) {
+	switch node := n.(type) {
+	case *ast.SendStmt:
+		site.record("concurrency", "send", types.ExprString(node.Chan), "", function, node.Pos())
+
+	case *ast.UnaryExpr:
+		if node.Op == token.ARROW {
+			site.record("concurrency", "receive", types.ExprString(node.X), "", function, node.Pos())
+		}
+
+	case *ast.RangeStmt:
+		// for v := range ch receives until the channel is closed
+		if isChannel(info, node.X) {
+			site.record("concurrency", "receive", types.ExprString(node.X), "", function, node.X.Pos())
+		}
+
+	case *ast.CallExpr:
+		switch fun := node.Fun.(type) {
+		case *ast.Ident:
+			if fun.Obj != nil || len(node.Args) == 0 {
+				return
+			}
+			if _, isChan := node.Args[0].(*ast.ChanType); fun.Name == "make" && isChan {
+				site.record("concurrency", "make", types.ExprString(node), "", function, node.Pos())
+			} else if fun.Name == "close" {
+				site.record("concurrency", "close", types.ExprString(node.Args[0]), "", function, node.Pos())
+			}
+
+		case *ast.SelectorExpr:
+			if x, ok := fun.X.(*ast.Ident); ok && x.Obj == nil && x.Name == "errgroup" && fun.Sel.Name == "WithContext" {
+				site.record("concurrency", "errgroup", types.ExprString(node.Fun)+"()", "", function, node.Pos())
+				return
+			}
+
+			syncType, target := a.syncValue(info, fun)
+			if !syncMethods[syncType][fun.Sel.Name] {
+				return
+			}
+			kind := "mutex"
+			switch syncType {
+			case "sync.WaitGroup":
+				kind = "waitgroup"
+			case "errgroup.Group":
+				kind = "errgroup"
+			}
+			site.record("concurrency", kind, types.ExprString(node.Fun)+"()", target, function, node.Pos())
+		}
+	}
+}
+
+// isChannel reports whether the type checker found an expression to be a channel
+func isChannel(info *types.Info, expr ast.Expr) bool {
+	t := info.TypeOf(expr)
+	if t == nil {
+		return false
+	}
+	_, isChan := t.Underlying().(*types.Chan)
+	return isChan
+}
+
+// syncValue returns the synchronisation type whose method a selector selects, such as sync.Mutex
+// for s.mu.Lock or for s.Lock on a struct embedding a mutex, and the field or variable holding
+// it, e.g. "Server.mu", or "" when it is held elsewhere
+func (a *Analysis) syncValue(info *types.Info, method *ast.SelectorExpr) (string, string) {
+	selection := info.Selections[method]
+	if selection == nil || selection.Kind() != types.MethodVal {
+		return "", ""
+	}
+	recv := selection.Obj().(*types.Func).Type().(*types.Signature).Recv()
+	named := namedType(recv.Type())
+	if named == nil || named.Obj().Pkg() == nil {
+		return "", ""
+	}
+	syncType := named.Obj().Pkg().Name() + "." + named.Obj().Name()
+	if syncMethods[syncType] == nil {
+		return "", ""
+	}
+
+	// Methods promoted from an embedded mutex or WaitGroup, e.g. s.Lock()
+	if indices := selection.Index(); len(indices) > 1 {
+		if owner, field := a.selectedField(selection.Recv(), indices[:len(indices)-1]); field != nil {
+			return syncType, owner.Name + "." + field.Name
+		}
+		return syncType, ""
+	}
+
+	switch x := ast.Unparen(method.X).(type) {
+	case *ast.Ident:
+		return syncType, x.Name
+	case *ast.SelectorExpr:
+		if owner, field := a.field(info, x); field != nil {
+			return syncType, owner.Name + "." + field.Name
+		}
+	}
+	return syncType, ""
+}
+
+// addConcurrencyToOutput adds the goroutines, channel operations, mutexes and WaitGroups of the module to the report
+func (a *Analysis) addConcurrencyToOutput(output *strings.Builder) {
+	goroutines := a.findingsOf("concurrency", "go")
+	channels := a.findingsOf("concurrency", "make", "send", "receive", "close")
+	locks := a.findingsOf("concurrency", "mutex")
+	groups := a.findingsOf("concurrency", "waitgroup", "errgroup")
+
+	type mutexRow struct {
+		owner, name, typeString string
+	}
+	var mutexes []mutexRow
+	for _, structNode := range structNodes(a.Nodes) {
+		for _, field := range structNode.Fields {
+			if isMutexType(field.Type) {
+				mutexes = append(mutexes, mutexRow{structNode.Name, field.Name, field.Type})
+			}
+		}
+	}
+	var variables []*CodeNode
+	for _, node := range a.Nodes {
+		if node.Type == "variable" && isMutexType(node.ValueType) {
+			variables = append(variables, node)
+		}
+	}
+	sortNodesByKey(variables)
+	for _, variable := range variables {
+		mutexes = append(mutexes, mutexRow{"", variable.Name, variable.ValueType})
+	}
+
+	output.WriteString("\n## Concurrency\n\n")
+	if len(goroutines)+len(channels)+len(locks)+len(groups)+len(mutexes) == 0 {
+		output.WriteString("*No goroutines, channels or synchronisation found.*\n")
+		return
+	}
+
+	if len(goroutines) > 0 {
+		output.WriteString("### Goroutines\n\n")
+		output.WriteString("| Launched From | Launches | Location |\n")
+		output.WriteString("|---------------|----------|----------|\n")
+		for _, finding := range goroutines {
+			output.WriteString(fmt.Sprintf("| `%s` | `%s` | %s |\n", graphLabel(finding.Function), finding.Detail, a.findingLink(finding)))
+		}
+		output.WriteString("\n")
+	}
+
+	if len(channels) > 0 {
+		output.WriteString("### Channels\n\n")
+		output.WriteString("| Function | Operation | Channel | Location |\n")
+		output.WriteString("|----------|-----------|---------|----------|\n")
+		for _, finding := range channels {
+			output.WriteString(fmt.Sprintf("| `%s` | %s | `%s` | %s |\n", graphLabel(finding.Function), finding.Kind, finding.Detail, a.findingLink(finding)))
+		}
+		output.WriteString("\n")
+	}
+
+	if len(mutexes) > 0 {
+		output.WriteString("### Mutexes\n\n")
+		output.WriteString("| Struct | Field | Type | Used In |\n")
+		output.WriteString("|--------|-------|------|---------|\n")
+		for _, mutex := range mutexes {
+			target := mutex.name
+			owner := "-"
+			if mutex.owner != "" {
+				target = mutex.owner + "." + mutex.name
+				owner = mutex.owner
+			}
+
+			var users []*CodeNode
+			for _, finding := range locks {
+				if finding.Target == target && !containsNode(users, finding.Function) {
+					users = append(users, finding.Function)
+				}
+			}
+			var names []string
+			for _, user := range users {
+				names = append(names, "`"+graphLabel(user)+"`")
+			}
+			usedIn := strings.Join(names, ", ")
+			if usedIn == "" {
+				usedIn = "-"
+			}
+
+			output.WriteString(fmt.Sprintf("| %s | %s | `%s` | %s |\n", owner, mutex.name, mutex.typeString, usedIn))
+		}
+		output.WriteString("\n")
+	}
+
+	if len(groups) > 0 {
+		output.WriteString("### WaitGroups and errgroups\n\n")
+		output.WriteString("| Function | Kind | Call | Location |\n")
+		output.WriteString("|----------|------|------|----------|\n")
+		for _, finding := range groups {
+			output.WriteString(fmt.Sprintf("| `%s` | %s | `%s` | %s |\n", graphLabel(finding.Function), finding.Kind, finding.Detail, a.findingLink(finding)))
+		}
+		output.WriteString("\n")
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChannelReceives(t *testing.T) {
+	analysis := analyzeSource(t, map[string]string{"fx.go": `package fx
+
+type Pool struct {
+	jobs chan int
+}
+
+var events = make(chan string)
+
+var names []string
+
+func results() <-chan int { return nil }
+
+func local() {
+	ch := make(chan int)
+	for v := range ch {
+		_ = v
+	}
+	<-ch
+}
+
+func param(in <-chan int) {
+	for range in {
+	}
+}
+
+func (p *Pool) field() {
+	for job := range p.jobs {
+		_ = job
+	}
+}
+
+func global() {
+	for e := range events {
+		_ = e
+	}
+	for _, name := range names {
+		_ = name
+	}
+}
+
+func call() {
+	for r := range results() {
+		_ = r
+	}
+	for i := range 3 {
+		_ = i
+	}
+}
+`})
+
+	var got []string
+	for _, finding := range analysis.findingsOf("concurrency", "receive") {
+		got = append(got, graphLabel(finding.Function)+" "+finding.Detail)
+	}
+	want := []string{"local ch", "local ch", "param in", "Pool.field p.jobs", "global events", "call results()"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("receives %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+)
+
+// resultTypes returns the result types of a function as written, one per result
+func resultTypes(funcType *ast.FuncType) []string {
+	var results []string
+	if funcType.Results == nil {
+		return results
+	}
+	for _, field := range funcType.Results.List {
+		for range max(len(field.Names), 1) {
+			results = append(results, types.ExprString(field.Type))
+		}
+	}
+	return results
+}
+
+// variadicType returns the element type of the final variadic parameter of a function as written, or ""
+func variadicType(funcType *ast.FuncType) string {
+	if funcType.Params == nil || len(funcType.Params.List) == 0 {
+		return ""
+	}
+	if ellipsis, ok := funcType.Params.List[len(funcType.Params.List)-1].Type.(*ast.Ellipsis); ok {
+		return types.ExprString(ellipsis.Elt)
+	}
+	return ""
+}
+
+// optionTarget returns the type a function type such as func(*Server) or func(*Server) error
+// could configure as a functional option, or "" for other function types. groupConstructors
+// only treats it as an option type when the package uses it as one.
+func optionTarget(funcType *ast.FuncType) string {
+	if funcType.Params == nil || len(funcType.Params.List) != 1 || len(funcType.Params.List[0].Names) > 1 {
+		return ""
+	}
+	if funcType.Results != nil && (len(funcType.Results.List) != 1 || types.ExprString(funcType.Results.List[0].Type) != "error") {
+		return ""
+	}
+
+	star, ok := funcType.Params.List[0].Type.(*ast.StarExpr)
+	if !ok {
+		return ""
+	}
+	if ident, ok := star.X.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+// constructedType returns the name of the type a function returns first, without pointer or type arguments
+func constructedType(function *CodeNode) string {
+	if len(function.Returns) == 0 {
+		return ""
+	}
+	name := strings.TrimPrefix(function.Returns[0], "*")
+	if i := strings.Index(name, "["); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// usedAsOption reports whether a package uses a function type as functional options: a New or Must
+// constructor of the type it configures takes a variadic list of them, or With functions return one
+func usedAsOption(packageNode *CodeNode, optionType *CodeNode) bool {
+	for _, child := range packageNode.Children {
+		if child.Type != "function" {
+			continue
+		}
+		if child.Variadic == optionType.Name && constructedType(child) == optionType.OptionFor &&
+			(strings.HasPrefix(child.Name, "New") || strings.HasPrefix(child.Name, "Must")) {
+			return true
+		}
+		if strings.HasPrefix(child.Name, "With") && len(child.Returns) == 1 && child.Returns[0] == optionType.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// groupConstructors moves the New and Must constructors of a package under the types they return,
+// as godoc does. Functional option types are moved under the type they configure, together with
+// the With functions returning them, when the package uses them as options.
+func groupConstructors(packageNode *CodeNode) {
+	typeNodes := make(map[string]*CodeNode)
+	for _, child := range packageNode.Children {
+		if child.Type == "struct" || child.Type == "interface" || child.Type == "type" {
+			typeNodes[child.Name] = child
+		}
+	}
+
+	// Nested nodes come before the methods and values already under the type
+	nested := make(map[*CodeNode][]*CodeNode)
+	optionTypes := make(map[string]*CodeNode)
+	var optionOrder []*CodeNode
+	for _, child := range packageNode.Children {
+		if child.OptionFor == "" {
+			continue
+		}
+		if target, exists := typeNodes[child.OptionFor]; exists && child.Type == "type" && target != child && usedAsOption(packageNode, child) {
+			child.Role = "options"
+			optionTypes[child.Name] = child
+			optionOrder = append(optionOrder, child)
+		} else {
+			child.OptionFor = "" // A plain callback type such as func(*Node), not an option
+		}
+	}
+
+	var children []*CodeNode
+	for _, child := range packageNode.Children {
+		if child.Type == "function" {
+			name := constructedType(child)
+			if optionType, exists := optionTypes[name]; exists && strings.HasPrefix(child.Name, "With") && len(child.Returns) == 1 {
+				child.Role = "option"
+				nested[optionType] = append(nested[optionType], child)
+				continue
+			}
+			if typeNode, exists := typeNodes[name]; exists && (strings.HasPrefix(child.Name, "New") || strings.HasPrefix(child.Name, "Must")) {
+				child.Role = "constructor"
+				nested[typeNode] = append(nested[typeNode], child)
+				continue
+			}
+		}
+		if child.Role == "options" {
+			continue // Added after the constructors of the type it configures
+		}
+		children = append(children, child)
+	}
+	packageNode.Children = children
+
+	for _, optionType := range optionOrder {
+		target := typeNodes[optionType.OptionFor]
+		nested[target] = append(nested[target], optionType)
+	}
+	for typeNode, nodes := range nested {
+		typeNode.Children = append(nodes, typeNode.Children...)
+	}
+}
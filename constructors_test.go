@@ -0,0 +1,62 @@
+package main
+
+import "testing"
+
+func TestGroupConstructors(t *testing.T) {
+	analysis := analyzeSource(t, map[string]string{"fx.go": `package fx
+
+type Server struct{}
+
+type Option func(*Server)
+
+func NewServer(opts ...Option) *Server { return &Server{} }
+
+type Client struct{}
+
+type ClientOption func(*Client) error
+
+func WithRetries(n int) ClientOption { return nil }
+
+type Node struct{}
+
+type Visitor func(*Node)
+
+func Walk(n *Node, visit Visitor) {}
+`})
+
+	tests := []struct {
+		key    string
+		role   string
+		parent string
+	}{
+		{".:fx:NewServer", "constructor", "Server"},
+		{".:fx:Option", "options", "Server"},
+		{".:fx:ClientOption", "options", "Client"},
+		{".:fx:WithRetries", "option", "ClientOption"},
+		{".:fx:Visitor", "", "fx"},
+		{".:fx:Walk", "", "fx"},
+	}
+
+	parents := make(map[*CodeNode]*CodeNode)
+	var walk func(node *CodeNode)
+	walk = func(node *CodeNode) {
+		for _, child := range node.Children {
+			parents[child] = node
+			walk(child)
+		}
+	}
+	walk(analysis.CodeRoot)
+
+	for _, test := range tests {
+		node := nodeByKey(t, analysis, test.key)
+		if node.Role != test.role {
+			t.Errorf("%s has role %q, want %q", test.key, node.Role, test.role)
+		}
+		if parent := parents[node]; parent == nil || parent.Name != test.parent {
+			t.Errorf("%s is under %v, want %s", test.key, parent, test.parent)
+		}
+	}
+	if visitor := nodeByKey(t, analysis, ".:fx:Visitor"); visitor.OptionFor != "" {
+		t.Errorf("Visitor is an option type for %s", visitor.OptionFor)
+	}
+}
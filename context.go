@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+)
+
+// contextParamIndex returns the position of the first context.Context parameter of a function, or -1
+func contextParamIndex(funcType *ast.FuncType, importMap map[string]string) int {
+	if funcType.Params == nil {
+		return -1
+	}
+
+	index := 0
+	for _, field := range funcType.Params.List {
+		if importedName(field.Type, importMap, "context") == "Context" {
+			return index
+		}
+		index += max(len(field.Names), 1)
+	}
+	return -1
+}
+
+// checkContextParam records a function whose context.Context is not its first parameter
+func checkContextParam(funcDecl *ast.FuncDecl, function *CodeNode, site findingSite, importMap map[string]string) {
+	index := contextParamIndex(funcDecl.Type, importMap)
+	if index <= 0 {
+		return
+	}
+	site.record("context", "not first", fmt.Sprintf("context.Context is parameter %d", index+1), "", function, funcDecl.Pos())
+}
+
+// recordContextCall records calls passing context.Background() or context.TODO() from a function
+// that was handed a context, which cuts the callee off from cancellation and deadlines
+func recordContextCall(call *ast.CallExpr, site findingSite, function *CodeNode, importMap map[string]string) {
+	for _, arg := range call.Args {
+		argCall, ok := arg.(*ast.CallExpr)
+		if !ok {
+			continue
+		}
+		switch importedName(argCall.Fun, importMap, "context") {
+		case "Background", "TODO":
+			site.record("context", "not propagated", types.ExprString(call.Fun)+"("+types.ExprString(argCall)+")", "", function, argCall.Pos())
+		}
+	}
+}
+
+// addContextToOutput adds the context propagation violations to the report
+func (a *Analysis) addContextToOutput(output *strings.Builder) {
+	violations := a.findingsOf("context")
+
+	output.WriteString("\n## Context Propagation\n\n")
+	if len(violations) == 0 {
+		output.WriteString("*No context propagation issues found.*\n")
+		return
+	}
+
+	output.WriteString("| Function | Issue | Detail | Location |\n")
+	output.WriteString("|----------|-------|--------|----------|\n")
+	for _, finding := range violations {
+		issue := "`context.Background()`/`context.TODO()` passed instead of the function's context"
+		if finding.Kind == "not first" {
+			issue = "context is not the first parameter"
+		}
+		output.WriteString(fmt.Sprintf("| `%s` | %s | `%s` | %s |\n", graphLabel(finding.Function), issue, finding.Detail, a.findingLink(finding)))
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestContextPropagation(t *testing.T) {
+	analysis := analyzeSource(t, map[string]string{"fx.go": `package fx
+
+import (
+	"context"
+	stdctx "context"
+)
+
+func fetch(ctx context.Context, id int) error { return nil }
+
+func store(id int, ctx context.Context) error { return nil }
+
+func late(a, b string, ctx stdctx.Context) {}
+
+func handle(ctx context.Context) {
+	fetch(ctx, 1)
+	fetch(context.Background(), 2)
+	fetch(stdctx.TODO(), 3)
+}
+
+func main() {
+	fetch(context.Background(), 4)
+}
+`})
+
+	var got []string
+	for _, finding := range analysis.findingsOf("context") {
+		got = append(got, graphLabel(finding.Function)+" "+finding.Kind+": "+finding.Detail)
+	}
+	want := []string{
+		"store not first: context.Context is parameter 2",
+		"late not first: context.Context is parameter 3",
+		"handle not propagated: fetch(context.Background())",
+		"handle not propagated: fetch(stdctx.TODO())",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("context findings = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// stronglyConnectedComponents computes the strongly connected components of the call graph
+// using Tarjan's algorithm. Components are returned in reverse topological order, so every
+// component comes before the components that call into it.
+func stronglyConnectedComponents(nodes map[string]*CodeNode) [][]*CodeNode {
+	functions := selectGraphNodes(nodes, "", 0)
+
+	index := make(map[*CodeNode]int)
+	lowLink := make(map[*CodeNode]int)
+	onStack := make(map[*CodeNode]bool)
+	var stack []*CodeNode
+	var components [][]*CodeNode
+	nextIndex := 0
+
+	var strongConnect func(node *CodeNode)
+	strongConnect = func(node *CodeNode) {
+		index[node] = nextIndex
+		lowLink[node] = nextIndex
+		nextIndex++
+		stack = append(stack, node)
+		onStack[node] = true
+
+		for _, calledNode := range node.Calls {
+			if _, visited := index[calledNode]; !visited {
+				strongConnect(calledNode)
+				lowLink[node] = min(lowLink[node], lowLink[calledNode])
+			} else if onStack[calledNode] {
+				lowLink[node] = min(lowLink[node], index[calledNode])
+			}
+		}
+
+		// node is the root of a component, pop it off the stack
+		if lowLink[node] == index[node] {
+			var component []*CodeNode
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				component = append(component, top)
+				if top == node {
+					break
+				}
+			}
+			sortNodesByKey(component)
+			components = append(components, component)
+		}
+	}
+
+	for _, node := range functions {
+		if _, visited := index[node]; !visited {
+			strongConnect(node)
+		}
+	}
+
+	return components
+}
+
+// detectCallCycles finds direct and mutual recursion in the call graph and records
+// the cycle each participating node belongs to in CodeNode.Cycle
+func detectCallCycles(nodes map[string]*CodeNode) [][]*CodeNode {
+	var cycles [][]*CodeNode
+	for _, component := range stronglyConnectedComponents(nodes) {
+		if len(component) == 1 && !functionCallExists(component[0], component[0]) {
+			continue
+		}
+		cycles = append(cycles, component)
+	}
+
+	// Order cycles by their first key so the numbering is stable
+	sort.Slice(cycles, func(i, j int) bool {
+		return cycles[i][0].Key < cycles[j][0].Key
+	})
+
+	for i, cycle := range cycles {
+		for _, node := range cycle {
+			node.Cycle = i + 1
+		}
+	}
+
+	return cycles
+}
+
+// isCycleEdge reports whether a call from caller to callee is part of a recursive cycle
+func isCycleEdge(caller, callee *CodeNode) bool {
+	return caller.Cycle != 0 && caller.Cycle == callee.Cycle
+}
+
+// addCallCyclesToOutput adds the recursion and call cycle section to the report
+func (a *Analysis) addCallCyclesToOutput(output *strings.Builder) {
+	output.WriteString("\n## Recursion and Call Cycles\n\n")
+	if len(a.Cycles) == 0 {
+		output.WriteString("*No recursive calls found.*\n")
+		return
+	}
+
+	output.WriteString("| # | Kind | Functions |\n")
+	output.WriteString("|---|------|-----------|\n")
+
+	for i, cycle := range a.Cycles {
+		kind := "Direct recursion"
+		if len(cycle) > 1 {
+			kind = fmt.Sprintf("Mutual recursion (%d functions)", len(cycle))
+		}
+
+		var functions []string
+		for _, node := range cycle {
+			functions = append(functions, fmt.Sprintf("`%s` (%s)", graphLabel(node), a.sourceLink(node)))
+		}
+
+		output.WriteString(fmt.Sprintf("| %d | %s | %s |\n", i+1, kind, strings.Join(functions, ", ")))
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDetectCallCycles(t *testing.T) {
+	analysis := analyzeSource(t, map[string]string{"fx.go": `package fx
+
+func even(n int) bool { return n == 0 || odd(n-1) }
+
+func odd(n int) bool { return n != 0 && even(n-1) }
+
+func fact(n int) int {
+	if n == 0 {
+		return 1
+	}
+	return n * fact(n-1)
+}
+
+type Tree struct{ Children []*Tree }
+
+func (t *Tree) Walk() { walkChildren(t) }
+
+func walkChildren(t *Tree) {
+	for _, child := range t.Children {
+		child.Walk()
+	}
+}
+
+func retry() {
+	again := func() { retry() }
+	again()
+}
+
+func walk() { helper() }
+
+func helper() {}
+`})
+
+	var got [][]string
+	for _, cycle := range detectCallCycles(analysis.Nodes) {
+		var keys []string
+		for _, node := range cycle {
+			keys = append(keys, node.Key)
+		}
+		got = append(got, keys)
+	}
+	want := [][]string{
+		{".:fx:Tree.Walk", ".:fx:walkChildren"},
+		{".:fx:even", ".:fx:odd"},
+		{".:fx:fact"},
+		{".:fx:retry", ".:fx:retry$1"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("cycles = %v, want %v", got, want)
+	}
+
+	for i, cycle := range want {
+		for _, key := range cycle {
+			if number := nodeByKey(t, analysis, key).Cycle; number != i+1 {
+				t.Errorf("%s is in cycle %d, want %d", key, number, i+1)
+			}
+		}
+	}
+	for _, key := range []string{".:fx:walk", ".:fx:helper"} {
+		if number := nodeByKey(t, analysis, key).Cycle; number != 0 {
+			t.Errorf("%s is in cycle %d", key, number)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// isSentinelError reports whether a node is a package-level error variable such as
+// var ErrNotFound = errors.New("not found")
+func isSentinelError(node *CodeNode) bool {
+	return node.Type == "variable" && (strings.HasPrefix(node.Value, "errors.New(") || strings.HasPrefix(node.Value, "fmt.Errorf("))
+}
+
+// isErrorType reports whether a type declares an Error() string method, implementing error
+func isErrorType(typeNode *CodeNode) bool {
+	for _, child := range typeNode.Children {
+		if child.Type == "method" && child.Name == "Error" && (child.Signature == "" || strings.HasSuffix(child.Signature, "Error() string")) {
+			return true
+		}
+	}
+	return false
+}
+
+// sentinelError returns the sentinel error an identifier or pkg.Name selector refers to, or nil
+func sentinelError(nodes map[string]*CodeNode, expr ast.Expr, packageKey string, importMap map[string]string) *CodeNode {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if e.Obj != nil && e.Obj.Kind != ast.Var {
+			return nil
+		}
+	case *ast.SelectorExpr:
+		if x, ok := e.X.(*ast.Ident); !ok || x.Obj != nil {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	if node, exists := nodes[resolveExpr(expr, packageKey, importMap)]; exists && isSentinelError(node) {
+		return node
+	}
+	return nil
+}
+
+// errorfFormat returns the format string of a fmt.Errorf call, or "" when it is not a literal
+func errorfFormat(call *ast.CallExpr) string {
+	if len(call.Args) == 0 {
+		return ""
+	}
+	literal, ok := call.Args[0].(*ast.BasicLit)
+	if !ok {
+		return ""
+	}
+	format, err := strconv.Unquote(literal.Value)
+	if err != nil {
+		return ""
+	}
+	return format
+}
+
+// recordErrorHandling records fmt.Errorf calls, with or without %w, and the sentinel errors
+// a function returns, directly or wrapped
+func (a *Analysis) recordErrorHandling(n ast.Node, site findingSite, function *CodeNode, packageKey string, importMap map[string]string) {
+	switch node := n.(type) {
+	case *ast.CallExpr:
+		if importedName(node.Fun, importMap, "fmt") != "Errorf" {
+			return
+		}
+		format := errorfFormat(node)
+		kind := "errorf"
+		if strings.Contains(format, "%w") {
+			kind = "wrap"
+		}
+		site.record("errors", kind, format, "", function, node.Pos())
+
+	case *ast.ReturnStmt:
+		for _, result := range node.Results {
+			if sentinel := sentinelError(a.Nodes, result, packageKey, importMap); sentinel != nil {
+				site.record("errors", "returns", "directly", sentinel.Key, function, result.Pos())
+				continue
+			}
+
+			// fmt.Errorf("...: %w", ErrNotFound) wraps the sentinel
+			call, ok := result.(*ast.CallExpr)
+			if !ok || importedName(call.Fun, importMap, "fmt") != "Errorf" || !strings.Contains(errorfFormat(call), "%w") {
+				continue
+			}
+			for _, arg := range call.Args[1:] {
+				if sentinel := sentinelError(a.Nodes, arg, packageKey, importMap); sentinel != nil {
+					site.record("errors", "returns", "wrapped", sentinel.Key, function, result.Pos())
+				}
+			}
+		}
+	}
+}
+
+// addErrorsToOutput adds the sentinel errors, custom error types and fmt.Errorf calls of the module to the report
+func (a *Analysis) addErrorsToOutput(output *strings.Builder) {
+	var sentinels, errorTypes []*CodeNode
+	for _, node := range a.Nodes {
+		if isSentinelError(node) {
+			sentinels = append(sentinels, node)
+		} else if isTypeNode(node) && isErrorType(node) {
+			errorTypes = append(errorTypes, node)
+		}
+	}
+	sortNodesByKey(sentinels)
+	sortNodesByKey(errorTypes)
+
+	returns := a.findingsOf("errors", "returns")
+	errorfs := a.findingsOf("errors", "errorf", "wrap")
+
+	output.WriteString("\n## Error Handling\n\n")
+	if len(sentinels)+len(errorTypes)+len(errorfs) == 0 {
+		output.WriteString("*No sentinel errors, custom error types or fmt.Errorf calls found.*\n")
+		return
+	}
+
+	if len(sentinels) > 0 {
+		output.WriteString("### Sentinel Errors\n\n")
+		output.WriteString("| Error | Location | Value | Returned By |\n")
+		output.WriteString("|-------|----------|-------|-------------|\n")
+		for _, sentinel := range sentinels {
+			var returnedBy []string
+			for _, finding := range returns {
+				if finding.Target != sentinel.Key {
+					continue
+				}
+				entry := "`" + graphLabel(finding.Function) + "`"
+				if finding.Detail == "wrapped" {
+					entry += " (wrapped)"
+				}
+				if !slices.Contains(returnedBy, entry) {
+					returnedBy = append(returnedBy, entry)
+				}
+			}
+			returned := strings.Join(returnedBy, ", ")
+			if returned == "" {
+				returned = "-"
+			}
+			output.WriteString(fmt.Sprintf("| %s | %s | `%s` | %s |\n", sentinel.Name, a.sourceLink(sentinel), sentinel.Value, returned))
+		}
+		output.WriteString("\n")
+	}
+
+	if len(errorTypes) > 0 {
+		output.WriteString("### Custom Error Types\n\n")
+		output.WriteString("| Type | Location |\n")
+		output.WriteString("|------|----------|\n")
+		for _, errorType := range errorTypes {
+			output.WriteString(fmt.Sprintf("| %s | %s |\n", errorType.Name, a.sourceLink(errorType)))
+		}
+		output.WriteString("\n")
+	}
+
+	if len(errorfs) > 0 {
+		wrapped := 0
+		for _, finding := range errorfs {
+			if finding.Kind == "wrap" {
+				wrapped++
+			}
+		}
+		output.WriteString("### fmt.Errorf Calls\n\n")
+		output.WriteString(fmt.Sprintf("*%d of %d calls wrap an error with `%%w`.*\n\n", wrapped, len(errorfs)))
+		output.WriteString("| Function | Wraps | Format | Location |\n")
+		output.WriteString("|----------|-------|--------|----------|\n")
+		for _, finding := range errorfs {
+			wraps := "no"
+			if finding.Kind == "wrap" {
+				wraps = "yes"
+			}
+			output.WriteString(fmt.Sprintf("| `%s` | %s | `%s` | %s |\n", graphLabel(finding.Function), wraps, strings.ReplaceAll(finding.Detail, "|", "\\|"), a.findingLink(finding)))
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestErrorHandling(t *testing.T) {
+	analysis := analyzeSource(t, map[string]string{
+		"store/store.go": `package store
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrNotFound = errors.New("not found")
+
+var ErrClosed = fmt.Errorf("closed")
+
+var limit = 10
+
+type QueryError struct{ Query string }
+
+func (e *QueryError) Error() string { return e.Query }
+
+type Result struct{}
+
+func (r Result) Error(code int) string { return "" }
+
+func Get(key string) error {
+	if key == "" {
+		return ErrNotFound
+	}
+	return fmt.Errorf("get %s: %w", key, ErrClosed)
+}
+`,
+		"fx.go": `package fx
+
+import (
+	"fmt"
+
+	"example.com/fx/store"
+)
+
+func load(key string) error {
+	if err := store.Get(key); err != nil {
+		return fmt.Errorf("load %s: %v", key, err)
+	}
+	return store.ErrNotFound
+}
+`,
+	})
+
+	for key, sentinel := range map[string]bool{
+		"store:store:ErrNotFound": true,
+		"store:store:ErrClosed":   true,
+		"store:store:limit":       false,
+	} {
+		if got := isSentinelError(nodeByKey(t, analysis, key)); got != sentinel {
+			t.Errorf("isSentinelError(%s) = %v, want %v", key, got, sentinel)
+		}
+	}
+	for key, errorType := range map[string]bool{
+		"store:store:QueryError": true,
+		"store:store:Result":     false, // Error has the wrong signature
+	} {
+		if got := isErrorType(nodeByKey(t, analysis, key)); got != errorType {
+			t.Errorf("isErrorType(%s) = %v, want %v", key, got, errorType)
+		}
+	}
+
+	var got []string
+	for _, finding := range analysis.findingsOf("errors") {
+		got = append(got, graphLabel(finding.Function)+" "+finding.Kind+" "+finding.Detail+" "+finding.Target)
+	}
+	want := []string{
+		"load errorf load %s: %v ",
+		"load returns directly store:store:ErrNotFound",
+		"Get returns directly store:store:ErrNotFound",
+		"Get returns wrapped store:store:ErrClosed",
+		"Get wrap get %s: %w ",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("error findings = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// isMustHelper reports whether a function name follows the Must convention, such as
+// regexp.MustCompile or template.Must, of panicking instead of returning an error
+func isMustHelper(name string) bool {
+	rest, isMust := strings.CutPrefix(name, "Must")
+	return isMust && (rest == "" || unicode.IsUpper([]rune(rest)[0]))
+}
+
+// recordExits records calls to panic, os.Exit, log.Fatal* and log.Panic*, and to Must helpers
+func recordExits(n ast.Node, site findingSite, function *CodeNode, importMap map[string]string) {
+	call, ok := n.(*ast.CallExpr)
+	if !ok {
+		return
+	}
+
+	kind := ""
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		if fun.Name == "panic" && fun.Obj == nil {
+			kind = "panic"
+		} else if isMustHelper(fun.Name) {
+			kind = "must"
+		}
+	case *ast.SelectorExpr:
+		if importedName(fun, importMap, "os") == "Exit" {
+			kind = "exit"
+		} else if name := importedName(fun, importMap, "log"); strings.HasPrefix(name, "Fatal") {
+			kind = "fatal"
+		} else if strings.HasPrefix(name, "Panic") {
+			kind = "panic"
+		} else if isMustHelper(fun.Sel.Name) {
+			kind = "must"
+		}
+	}
+	if kind != "" {
+		site.record("exits", kind, types.ExprString(call.Fun), "", function, call.Pos())
+	}
+}
+
+// exitAllowed reports whether a package directory may call os.Exit and log.Fatal under
+// ExitAllowed, a comma separated list of directories allowed along with their subdirectories
+func (o *Options) exitAllowed(dir string) bool {
+	for _, allowed := range strings.Split(o.ExitAllowed, ",") {
+		allowed = strings.Trim(strings.TrimSpace(allowed), "/")
+		if allowed == "" {
+			continue
+		}
+		if allowed == "." || dir == allowed || strings.HasPrefix(dir, allowed+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// exitViolations returns the calls to os.Exit and log.Fatal* outside the directories allowed by
+// -exit-allowed, or nothing when the rule is not enabled
+func (a *Analysis) exitViolations() []*Finding {
+	if a.ExitAllowed == "" {
+		return nil
+	}
+	var violations []*Finding
+	for _, finding := range a.findingsOf("exits", "exit", "fatal") {
+		dir, _, _ := strings.Cut(packageKeyOf(finding.Function), ":")
+		if !a.exitAllowed(dir) {
+			violations = append(violations, finding)
+		}
+	}
+	return violations
+}
+
+// entryChains returns, for every function reachable from an entry point, the shortest chain
+// of calls leading to it. Functions passed as values are assumed to be called by the receiver.
+func entryChains(nodes map[string]*CodeNode) map[*CodeNode][]*CodeNode {
+	var entryPoints []*CodeNode
+	for _, node := range nodes {
+		if isEntryPoint(node) {
+			entryPoints = append(entryPoints, node)
+		}
+	}
+	sortNodesByKey(entryPoints)
+
+	chains := make(map[*CodeNode][]*CodeNode)
+	queue := entryPoints
+	for _, entryPoint := range entryPoints {
+		chains[entryPoint] = []*CodeNode{entryPoint}
+	}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range append(append([]*CodeNode{}, current.Calls...), current.References...) {
+			if _, seen := chains[next]; seen || !isCallable(next) {
+				continue
+			}
+			chains[next] = append(append([]*CodeNode{}, chains[current]...), next)
+			queue = append(queue, next)
+		}
+	}
+	return chains
+}
+
+// addExitsToOutput adds the calls that panic or end the process, the shortest chain reaching
+// each from an entry point and the violations of the -exit-allowed rule to the report
+func (a *Analysis) addExitsToOutput(output *strings.Builder) {
+	exits := a.findingsOf("exits")
+
+	output.WriteString("\n## Panics and Exits\n\n")
+	if len(exits) == 0 {
+		output.WriteString("*No calls to panic, os.Exit, log.Fatal or Must helpers found.*\n")
+		return
+	}
+
+	counts := make(map[string]int)
+	for _, finding := range exits {
+		counts[finding.Kind]++
+	}
+	output.WriteString(fmt.Sprintf("*%d panic, %d os.Exit, %d log.Fatal and %d Must calls.*\n\n", counts["panic"], counts["exit"], counts["fatal"], counts["must"]))
+
+	violations := a.exitViolations()
+	if len(violations) > 0 {
+		output.WriteString(fmt.Sprintf("> **%d calls end the process outside `%s`**, see `-exit-allowed`.\n\n", len(violations), a.ExitAllowed))
+	}
+
+	sorted := append([]*Finding{}, exits...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return packageKeyOf(sorted[i].Function) < packageKeyOf(sorted[j].Function)
+	})
+
+	chains := entryChains(a.Nodes)
+	output.WriteString("| Package | Function | Call | Location | Reached From |\n")
+	output.WriteString("|---------|----------|------|----------|--------------|\n")
+	for _, finding := range sorted {
+		call := "`" + finding.Detail + "`"
+		for _, violation := range violations {
+			if violation == finding {
+				call = "**" + call + "** (not allowed)"
+			}
+		}
+
+		reached := "-"
+		if chain, reachable := chains[finding.Function]; reachable {
+			var steps []string
+			for _, step := range chain {
+				steps = append(steps, "`"+graphLabel(step)+"`")
+			}
+			reached = strings.Join(steps, " → ")
+		}
+
+		output.WriteString(fmt.Sprintf("| %s | `%s` | %s | %s | %s |\n", packageKeyOf(finding.Function), graphLabel(finding.Function), call, a.findingLink(finding), reached))
+	}
+}
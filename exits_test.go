@@ -0,0 +1,114 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExits(t *testing.T) {
+	analysis := analyzeSource(t, map[string]string{
+		"cmd/tool/main.go": `package main
+
+import (
+	"os"
+
+	"example.com/fx/server"
+)
+
+func main() {
+	if err := server.Run(); err != nil {
+		os.Exit(1)
+	}
+}
+`,
+		"server/server.go": `package server
+
+import (
+	"log"
+	"os"
+	"regexp"
+	"text/template"
+)
+
+var pattern = regexp.MustCompile("a+")
+
+func Run() error {
+	load()
+	return nil
+}
+
+func load() {
+	if _, err := os.Stat("config"); err != nil {
+		log.Fatalf("no config: %v", err)
+	}
+	template.Must(template.New("t").Parse("x"))
+}
+
+func unused() {
+	log.Panicln("unreachable")
+	os.Exit(2)
+}
+
+func MustParse(s string) int {
+	panic(s)
+}
+
+func parse() int {
+	return MustParse("1")
+}
+`,
+	})
+
+	var got []string
+	for _, finding := range analysis.findingsOf("exits") {
+		got = append(got, graphLabel(finding.Function)+" "+finding.Kind+" "+finding.Detail)
+	}
+	want := []string{
+		"main exit os.Exit",
+		"load fatal log.Fatalf",
+		"load must template.Must",
+		"unused panic log.Panicln",
+		"unused exit os.Exit",
+		"MustParse panic panic",
+		"parse must MustParse",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("exit findings = %q, want %q", got, want)
+	}
+
+	// Only the calls ending the process outside cmd/ are violations
+	analysis.ExitAllowed = "cmd"
+	var violations []string
+	for _, finding := range analysis.exitViolations() {
+		violations = append(violations, graphLabel(finding.Function)+" "+finding.Detail)
+	}
+	if want := []string{"load log.Fatalf", "unused os.Exit"}; !reflect.DeepEqual(violations, want) {
+		t.Errorf("violations = %q, want %q", violations, want)
+	}
+
+	chains := entryChains(analysis.Nodes)
+	var chain []string
+	for _, step := range chains[nodeByKey(t, analysis, "server:server:load")] {
+		chain = append(chain, graphLabel(step))
+	}
+	if want := []string{"main", "Run", "load"}; !reflect.DeepEqual(chain, want) {
+		t.Errorf("chain to load = %v, want %v", chain, want)
+	}
+	if _, reachable := chains[nodeByKey(t, analysis, "server:server:unused")]; reachable {
+		t.Error("unused is reached from an entry point")
+	}
+}
+
+func TestIsMustHelper(t *testing.T) {
+	for name, want := range map[string]bool{
+		"Must":        true,
+		"MustCompile": true,
+		"Mustang":     false,
+		"mustParse":   false,
+		"Parse":       false,
+	} {
+		if got := isMustHelper(name); got != want {
+			t.Errorf("isMustHelper(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
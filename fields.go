@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// field resolves a selector to the struct field of the module it accesses, including fields
+// promoted from embedded structs, using the selections found by the type checker. Methods,
+// package-qualified names and fields of types from outside the module resolve to nil.
+func (a *Analysis) field(info *types.Info, selector *ast.SelectorExpr) (*CodeNode, *FieldInfo) {
+	selection := info.Selections[selector]
+	if selection == nil || selection.Kind() != types.FieldVal {
+		return nil, nil
+	}
+	return a.selectedField(selection.Recv(), selection.Index())
+}
+
+// selectedField returns the struct of the module declaring the field reached by following the
+// field indices of a selection from its receiver type, and that field, or nil when the struct
+// is not part of the module
+func (a *Analysis) selectedField(recv types.Type, indices []int) (*CodeNode, *FieldInfo) {
+	if len(indices) == 0 {
+		return nil, nil
+	}
+	owner := recv
+	for _, index := range indices[:len(indices)-1] {
+		structType := underlyingStruct(owner)
+		if structType == nil {
+			return nil, nil
+		}
+		owner = structType.Field(index).Type()
+	}
+	structType := underlyingStruct(owner)
+	structNode := a.typeNode(owner)
+	if structType == nil || structNode == nil {
+		return nil, nil
+	}
+
+	name := structType.Field(indices[len(indices)-1]).Name()
+	for _, field := range structNode.Fields {
+		if field.Name == name {
+			return structNode, field
+		}
+	}
+	return nil, nil
+}
+
+// underlyingStruct returns the struct a type is or points to, or nil
+func underlyingStruct(t types.Type) *types.Struct {
+	if pointer, ok := t.Underlying().(*types.Pointer); ok {
+		t = pointer.Elem()
+	}
+	structType, _ := t.Underlying().(*types.Struct)
+	return structType
+}
+
+// recordFieldWrites records the function as a writer of every struct field assigned,
+// incremented or decremented by a statement, including writes to the field's elements.
+// The selectors written are added to written, so they are not counted as reads.
+func (a *Analysis) recordFieldWrites(info *types.Info, stmt ast.Node, writer *CodeNode, written map[*ast.SelectorExpr]bool) {
+	var targets []ast.Expr
+	switch node := stmt.(type) {
+	case *ast.AssignStmt:
+		if node.Tok == token.DEFINE {
+			return
+		}
+		targets = node.Lhs
+	case *ast.IncDecStmt:
+		targets = []ast.Expr{node.X}
+	default:
+		return
+	}
+
+	for _, target := range targets {
+		selector := fieldTarget(target)
+		if selector == nil {
+			continue
+		}
+		if _, field := a.field(info, selector); field != nil {
+			written[selector] = true
+			if !containsNode(field.WrittenBy, writer) {
+				field.WrittenBy = append(field.WrittenBy, writer)
+			}
+		}
+	}
+}
+
+// fieldTarget returns the outermost selector of an assignment target, e.g. n.Calls in n.Calls[i] = c
+func fieldTarget(target ast.Expr) *ast.SelectorExpr {
+	for {
+		switch t := target.(type) {
+		case *ast.ParenExpr:
+			target = t.X
+		case *ast.StarExpr:
+			target = t.X
+		case *ast.IndexExpr:
+			target = t.X
+		case *ast.SelectorExpr:
+			return t
+		default:
+			return nil
+		}
+	}
+}
+
+// recordFieldRead records the function as a reader of the field a selector accesses,
+// unless the selector is the target of a write
+func (a *Analysis) recordFieldRead(info *types.Info, selector *ast.SelectorExpr, reader *CodeNode, written map[*ast.SelectorExpr]bool) {
+	if written[selector] {
+		return
+	}
+	if _, field := a.field(info, selector); field != nil && !containsNode(field.ReadBy, reader) {
+		field.ReadBy = append(field.ReadBy, reader)
+	}
+}
+
+// addFieldAccessToOutput adds, per struct, the functions reading and writing each field to the report.
+// Writers from outside the struct's package are flagged, as they stand in the way of encapsulation.
+func (a *Analysis) addFieldAccessToOutput(output *strings.Builder) {
+	headerWritten := false
+
+	accessList := func(functions []*CodeNode, owner *CodeNode) string {
+		if len(functions) == 0 {
+			return "-"
+		}
+		var names []string
+		for _, function := range functions {
+			name := "`" + graphLabel(function) + "`"
+			if owner != nil && packageKeyOf(function) != packageKeyOf(owner) {
+				name = "**" + name + " (external)**"
+			}
+			names = append(names, name)
+		}
+		return strings.Join(names, ", ")
+	}
+
+	for _, structNode := range structNodes(a.Nodes) {
+		accessed := false
+		for _, field := range structNode.Fields {
+			accessed = accessed || len(field.ReadBy) > 0 || len(field.WrittenBy) > 0
+		}
+		if !accessed {
+			continue
+		}
+
+		if !headerWritten {
+			output.WriteString("\n## Field Access\n")
+			headerWritten = true
+		}
+		output.WriteString(fmt.Sprintf("\n### `%s` (%s)\n\n", structNode.Name, a.sourceLink(structNode)))
+		output.WriteString("| Field | Type | Read By | Written By |\n")
+		output.WriteString("|-------|------|---------|------------|\n")
+		for _, field := range structNode.Fields {
+			output.WriteString(fmt.Sprintf("| %s | `%s` | %s | %s |\n",
+				field.Name, field.Type, accessList(field.ReadBy, nil), accessList(field.WrittenBy, structNode)))
+		}
+	}
+}
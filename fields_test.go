@@ -0,0 +1,85 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFieldAccess(t *testing.T) {
+	analysis := analyzeSource(t, map[string]string{"fx.go": `package fx
+
+import "go/token"
+
+type Point struct {
+	Line int
+	Tags []string
+}
+
+func newPoint() *Point { return &Point{} }
+
+func points() []*Point { return nil }
+
+func foreign(fset *token.FileSet, p token.Pos) int {
+	pos := fset.Position(p)
+	return pos.Line
+}
+
+func fromCall() int {
+	p := newPoint()
+	p.Line = 1
+	return p.Line
+}
+
+func fromRange() {
+	for _, p := range points() {
+		p.Tags = nil
+	}
+}
+
+func fromMake() int {
+	byName := make(map[string]*Point)
+	return byName["a"].Line
+}
+
+type Labeled struct {
+	Point
+	Label string
+}
+
+func promoted(l Labeled) int {
+	return l.Line + newPoint().Line
+}
+`})
+
+	fields := make(map[string]*FieldInfo)
+	for _, field := range nodeByKey(t, analysis, ".:fx:Point").Fields {
+		fields[field.Name] = field
+	}
+
+	tests := []struct {
+		field   string
+		readBy  []string
+		written []string
+	}{
+		{"Line", []string{"fromCall", "fromMake", "promoted"}, []string{"fromCall"}},
+		{"Tags", nil, []string{"fromRange"}},
+	}
+	for _, test := range tests {
+		field := fields[test.field]
+		if got := nodeNames(field.ReadBy); !reflect.DeepEqual(got, test.readBy) {
+			t.Errorf("%s read by %v, want %v", test.field, got, test.readBy)
+		}
+		if got := nodeNames(field.WrittenBy); !reflect.DeepEqual(got, test.written) {
+			t.Errorf("%s written by %v, want %v", test.field, got, test.written)
+		}
+	}
+}
+
+// nodeNames returns the names of nodes, in order, or nil when there are none
+func nodeNames(nodes []*CodeNode) []string {
+	var names []string
+	for _, node := range nodes {
+		names = append(names, node.Name)
+	}
+	return names
+}
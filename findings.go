@@ -0,0 +1,74 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"slices"
+)
+
+// Finding is a notable statement or call in a function body, such as a go statement
+type Finding struct {
+	Category string    // Report section the finding belongs to, e.g. "concurrency"
+	Kind     string    // What was found, e.g. "go" or "send"
+	Detail   string    // The expression as written
+	Target   string    // What the finding acts on, e.g. "Server.mu" for a lock, when known
+	Function *CodeNode // Function or closure containing it
+	FilePath string
+	Line     int
+}
+
+// findingSite records findings for the file being analysed
+type findingSite struct {
+	analysis *Analysis
+	fset     *token.FileSet
+	relPath  string
+}
+
+// record adds a finding at a position in the file
+func (s findingSite) record(category, kind, detail, target string, function *CodeNode, pos token.Pos) *Finding {
+	finding := &Finding{
+		Category: category,
+		Kind:     kind,
+		Detail:   detail,
+		Target:   target,
+		Function: function,
+		FilePath: s.relPath,
+		Line:     s.fset.Position(pos).Line,
+	}
+	s.analysis.Findings = append(s.analysis.Findings, finding)
+	return finding
+}
+
+// findingsOf returns the findings of a category, optionally narrowed to some kinds
+func (a *Analysis) findingsOf(category string, kinds ...string) []*Finding {
+	var matched []*Finding
+	for _, finding := range a.Findings {
+		if finding.Category != category {
+			continue
+		}
+		if len(kinds) > 0 && !slices.Contains(kinds, finding.Kind) {
+			continue
+		}
+		matched = append(matched, finding)
+	}
+	return matched
+}
+
+// findingLink returns a markdown link to the finding's source location
+func (o *Options) findingLink(finding *Finding) string {
+	return o.sourceLink(&CodeNode{FilePath: finding.FilePath, Line: finding.Line, EndLine: finding.Line})
+}
+
+// importedName returns the name selected from an imported package, e.g. "Exit" for os.Exit
+// when importPath is "os", or "" when the expression does not select from that package
+func importedName(expr ast.Expr, importMap map[string]string, importPath string) string {
+	selector, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	x, ok := selector.X.(*ast.Ident)
+	if !ok || x.Obj != nil || importMap[x.Name] != importPath {
+		return ""
+	}
+	return selector.Sel.Name
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+)
+
+// recordInstantiation records an explicit instantiation such as Set[int] or Map[string, int]
+// on the generic function or type it instantiates. Instantiations with type parameters
+// that are in scope, like the receiver in func (s *Set[T]) Add, are not concrete and skipped.
+func (a *Analysis) recordInstantiation(expr ast.Expr, packageKey string, importMap map[string]string, typeParams map[string]bool) {
+	var genericExpr ast.Expr
+	var typeArgs []ast.Expr
+	switch index := expr.(type) {
+	case *ast.IndexExpr:
+		genericExpr = index.X
+		typeArgs = []ast.Expr{index.Index}
+	case *ast.IndexListExpr:
+		genericExpr = index.X
+		typeArgs = index.Indices
+	default:
+		return
+	}
+
+	for _, typeArg := range typeArgs {
+		concrete := true
+		ast.Inspect(typeArg, func(n ast.Node) bool {
+			if ident, ok := n.(*ast.Ident); ok && typeParams[ident.Name] {
+				concrete = false
+			}
+			return concrete
+		})
+		if !concrete {
+			return
+		}
+	}
+
+	// Plain slice and map indexing resolves to nothing generic and is skipped here
+	genericNode, exists := a.Nodes[resolveExpr(genericExpr, packageKey, importMap)]
+	if !exists || genericNode.TypeParams == "" {
+		return
+	}
+
+	instantiation := types.ExprString(expr)
+	for _, existing := range genericNode.Instantiations {
+		if existing == instantiation {
+			return
+		}
+	}
+	genericNode.Instantiations = append(genericNode.Instantiations, instantiation)
+}
+
+// addGenericsToOutput adds the generic functions and types, and their instantiations, to the report
+func (a *Analysis) addGenericsToOutput(output *strings.Builder) {
+	var generics []*CodeNode
+	for _, node := range a.Nodes {
+		if node.TypeParams != "" {
+			generics = append(generics, node)
+		}
+	}
+	if len(generics) == 0 {
+		return
+	}
+	sortNodesByKey(generics)
+
+	output.WriteString("\n## Generics\n\n")
+	output.WriteString("*Only explicit instantiations are listed, inferred type arguments are not visible without type checking.*\n\n")
+	output.WriteString("| Name | Kind | Type Parameters | Location | Instantiations |\n")
+	output.WriteString("|------|------|-----------------|----------|----------------|\n")
+
+	for _, node := range generics {
+		instantiations := "-"
+		if len(node.Instantiations) > 0 {
+			instantiations = "`" + strings.Join(node.Instantiations, "`, `") + "`"
+		}
+		output.WriteString(fmt.Sprintf("| %s | %s | `%s` | %s | %s |\n",
+			node.Name, node.Type, node.TypeParams, a.sourceLink(node), instantiations))
+	}
+}
+
+// funcTypeParams returns the type parameters in scope in a function, declared on the
+// function itself or on a generic receiver
+func funcTypeParams(funcDecl *ast.FuncDecl) map[string]bool {
+	typeParams := make(map[string]bool)
+	addTypeParams(typeParams, funcDecl.Type.TypeParams)
+
+	if funcDecl.Recv != nil && len(funcDecl.Recv.List) > 0 {
+		receiverType := funcDecl.Recv.List[0].Type
+		if star, ok := receiverType.(*ast.StarExpr); ok {
+			receiverType = star.X
+		}
+
+		var indices []ast.Expr
+		switch index := receiverType.(type) {
+		case *ast.IndexExpr:
+			indices = []ast.Expr{index.Index}
+		case *ast.IndexListExpr:
+			indices = index.Indices
+		}
+		for _, index := range indices {
+			if ident, ok := index.(*ast.Ident); ok {
+				typeParams[ident.Name] = true
+			}
+		}
+	}
+
+	return typeParams
+}
+
+// addTypeParams adds the names in a type parameter list to the set of type parameters in scope
+func addTypeParams(typeParams map[string]bool, list *ast.FieldList) {
+	if list == nil || typeParams == nil {
+		return
+	}
+	for _, field := range list.List {
+		for _, name := range field.Names {
+			typeParams[name.Name] = true
+		}
+	}
+}
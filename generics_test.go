@@ -0,0 +1,30 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestInstantiationsInsideGenericFunction(t *testing.T) {
+	analysis := analyzeSource(t, map[string]string{"fx.go": `package fx
+
+type Set[T comparable] map[T]struct{}
+
+func Keys[T comparable](values []T) Set[T] {
+	var s Set[T]
+	type pair struct{ a, b int }
+	const limit = 10
+	var ints Set[int]
+	_, _ = ints, pair{}
+	return s
+}
+`})
+
+	set := nodeByKey(t, analysis, ".:fx:Set")
+	if slices.Contains(set.Instantiations, "Set[T]") {
+		t.Errorf("type parameter T recorded as an instantiation: %v", set.Instantiations)
+	}
+	if !slices.Contains(set.Instantiations, "Set[int]") {
+		t.Errorf("Set[int] not recorded: %v", set.Instantiations)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// recordGlobalWrites records the function as a writer of every package-level variable a node
+// can change: assigned, incremented or decremented, including writes to its fields and elements,
+// its address taken, e.g. &v passed to flag.StringVar or to a helper filling it in, or a method
+// with a pointer receiver called on it, such as mu.Lock() or v.Set()
+func (a *Analysis) recordGlobalWrites(info *types.Info, n ast.Node, writer *CodeNode) {
+	var targets []ast.Expr
+	switch node := n.(type) {
+	case *ast.AssignStmt:
+		if node.Tok == token.DEFINE {
+			return // := only declares local variables
+		}
+		targets = node.Lhs
+	case *ast.IncDecStmt:
+		targets = []ast.Expr{node.X}
+	case *ast.UnaryExpr:
+		if node.Op != token.AND {
+			return
+		}
+		targets = []ast.Expr{node.X}
+	case *ast.CallExpr:
+		selector, ok := ast.Unparen(node.Fun).(*ast.SelectorExpr)
+		if !ok || !takesReceiverAddress(info, selector) {
+			return
+		}
+		targets = []ast.Expr{selector.X}
+	default:
+		return
+	}
+
+	for _, target := range targets {
+		variable := a.packageVariable(info, target)
+		if variable == nil {
+			continue
+		}
+		if !containsNode(variable.WrittenBy, writer) {
+			variable.WrittenBy = append(variable.WrittenBy, writer)
+		}
+	}
+}
+
+// takesReceiverAddress reports whether a method call implicitly takes the address of its
+// receiver, calling a method with a pointer receiver on a value, e.g. mu.Lock() on a sync.Mutex
+func takesReceiverAddress(info *types.Info, selector *ast.SelectorExpr) bool {
+	selection := info.Selections[selector]
+	if selection == nil || selection.Kind() != types.MethodVal {
+		return false
+	}
+	recv := selection.Obj().(*types.Func).Type().(*types.Signature).Recv()
+	if recv == nil {
+		return false // Interface methods
+	}
+	if _, pointerMethod := recv.Type().(*types.Pointer); !pointerMethod {
+		return false
+	}
+	_, pointerValue := selection.Recv().Underlying().(*types.Pointer)
+	return !pointerValue
+}
+
+// packageVariable returns the package-level variable node at the root of a written expression,
+// such as nodes in nodes[key] = node or log in log.Verbose = true, or nil for locals
+func (a *Analysis) packageVariable(info *types.Info, target ast.Expr) *CodeNode {
+	for {
+		switch t := target.(type) {
+		case *ast.ParenExpr:
+			target = t.X
+		case *ast.StarExpr:
+			target = t.X
+		case *ast.IndexExpr:
+			target = t.X
+		case *ast.SelectorExpr:
+			if selection := info.Selections[t]; selection != nil {
+				target = t.X // A field of the variable
+				continue
+			}
+			target = t.Sel // A variable of another package, e.g. pkg.Var = value
+		case *ast.Ident:
+			variable, ok := info.Uses[t].(*types.Var)
+			if !ok || variable.Pkg() == nil || variable.Parent() != variable.Pkg().Scope() {
+				return nil
+			}
+			if node, exists := a.Nodes[a.objectKey(variable)]; exists && node.Type == "variable" {
+				return node
+			}
+			return nil
+		default:
+			return nil
+		}
+	}
+}
+
+// containsNode reports whether a node is in a list
+func containsNode(nodes []*CodeNode, node *CodeNode) bool {
+	for _, existing := range nodes {
+		if existing == node {
+			return true
+		}
+	}
+	return false
+}
+
+// addGlobalStateToOutput lists every package-level variable, classified by whether function bodies write to it
+func (a *Analysis) addGlobalStateToOutput(output *strings.Builder) {
+	var variables []*CodeNode
+	for _, node := range a.Nodes {
+		if node.Type == "variable" {
+			variables = append(variables, node)
+		}
+	}
+	if len(variables) == 0 {
+		return
+	}
+	sortNodesByKey(variables)
+
+	mutated := 0
+	for _, variable := range variables {
+		if globalStatus(variable) == "mutated" {
+			mutated++
+		}
+	}
+
+	output.WriteString("\n## Package-Level Variables\n\n")
+	output.WriteString(fmt.Sprintf("*%d of %d package-level variables are mutated by functions.*\n\n", mutated, len(variables)))
+	output.WriteString("| Variable | Package | Type | Status | Written By | Location |\n")
+	output.WriteString("|----------|---------|------|--------|------------|----------|\n")
+
+	for _, variable := range variables {
+		writers := "-"
+		if len(variable.WrittenBy) > 0 {
+			var names []string
+			for _, writer := range variable.WrittenBy {
+				names = append(names, fmt.Sprintf("`%s` (%s)", graphLabel(writer), a.sourceLink(writer)))
+			}
+			writers = strings.Join(names, ", ")
+		}
+
+		valueType := variable.ValueType
+		if valueType == "" {
+			valueType = "-"
+		} else {
+			valueType = "`" + valueType + "`"
+		}
+
+		output.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s | %s |\n",
+			variable.Name, packageKeyOf(variable), valueType, globalStatus(variable), writers, a.sourceLink(variable)))
+	}
+}
+
+// globalStatus classifies a package-level variable as effectively constant, set up in init, or mutated
+func globalStatus(variable *CodeNode) string {
+	if len(variable.WrittenBy) == 0 {
+		return "effectively constant"
+	}
+	for _, writer := range variable.WrittenBy {
+		if writer.Name != "init" || writer.Type != "function" {
+			return "mutated"
+		}
+	}
+	return "set in init"
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGlobalWrites(t *testing.T) {
+	analysis := analyzeSource(t, map[string]string{
+		"fx.go": `package fx
+
+import (
+	"encoding/json"
+	"flag"
+	"sync"
+
+	"example.com/fx/config"
+)
+
+type Counter struct{ n int }
+
+func (c *Counter) Inc()     { c.n++ }
+func (c Counter) Value() int { return c.n }
+
+var (
+	name     string
+	settings map[string]string
+	mu       sync.Mutex
+	counter  Counter
+	shared   = &Counter{}
+	limits   struct{ Max int }
+	table    = []int{1, 2, 3}
+	version  = "1.0"
+)
+
+func init() {
+	flag.StringVar(&name, "name", "", "")
+}
+
+func load(data []byte) {
+	json.Unmarshal(data, &settings)
+}
+
+func fill(max *int) { *max = 10 }
+
+func setup() {
+	fill(&limits.Max)
+	config.Debug = true
+}
+
+func lock() {
+	mu.Lock()
+	defer mu.Unlock()
+}
+
+func count() int {
+	counter.Inc()
+	shared.Inc()
+	return counter.Value()
+}
+
+func first() int {
+	version := "local"
+	_ = version
+	return table[0]
+}
+`,
+		"config/config.go": `package config
+
+var Debug bool
+`,
+	})
+
+	tests := []struct {
+		key     string
+		written []string
+		status  string
+	}{
+		{".:fx:name", []string{"init"}, "set in init"},
+		{".:fx:settings", []string{"load"}, "mutated"},
+		{".:fx:limits", []string{"setup"}, "mutated"},
+		{"config:config:Debug", []string{"setup"}, "mutated"},
+		{".:fx:mu", []string{"lock"}, "mutated"},
+		{".:fx:counter", []string{"count"}, "mutated"},
+		{".:fx:shared", nil, "effectively constant"}, // Inc changes what shared points to, not shared
+		{".:fx:table", nil, "effectively constant"},
+		{".:fx:version", nil, "effectively constant"},
+	}
+	for _, test := range tests {
+		variable := nodeByKey(t, analysis, test.key)
+		if got := nodeNames(variable.WrittenBy); !reflect.DeepEqual(got, test.written) {
+			t.Errorf("%s written by %v, want %v", test.key, got, test.written)
+		}
+		if got := globalStatus(variable); got != test.status {
+			t.Errorf("%s is %s, want %s", test.key, got, test.status)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// errorCall describes the callee of a call as found by the type checker
+type errorCall struct {
+	name       string // Callee, e.g. "os.Remove", "os.File.Close" or "store.DB.Save"
+	results    int
+	errorIndex int // Position of the error among the results, -1 when the callee returns none
+}
+
+// typedCalls returns the callee of every call of a package the type checker could type
+func typedCalls(pkg *loadedPackage) map[*ast.CallExpr]errorCall {
+	calls := make(map[*ast.CallExpr]errorCall)
+	for _, file := range pkg.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			if call, ok := n.(*ast.CallExpr); ok {
+				if callee, typed := typedCallee(call, pkg.Info); typed {
+					calls[call] = callee
+				}
+			}
+			return true
+		})
+	}
+	return calls
+}
+
+// typedCallee returns the callee of a call and where its error result is, or false when the
+// call was not typed or is a conversion or a call to a builtin
+func typedCallee(call *ast.CallExpr, info *types.Info) (errorCall, bool) {
+	fun, typed := info.Types[call.Fun]
+	if !typed || fun.IsType() || fun.IsBuiltin() {
+		return errorCall{}, false
+	}
+	signature, ok := fun.Type.Underlying().(*types.Signature)
+	if !ok {
+		return errorCall{}, false
+	}
+
+	callee := errorCall{name: types.ExprString(call.Fun), results: signature.Results().Len(), errorIndex: -1}
+	for i := range signature.Results().Len() {
+		if isErrorInterface(signature.Results().At(i).Type()) {
+			callee.errorIndex = i
+		}
+	}
+
+	// Name functions and methods by package and type rather than by the expression calling them
+	var name *ast.Ident
+	switch f := ast.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		name = f
+	case *ast.SelectorExpr:
+		name = f.Sel
+	case *ast.IndexExpr:
+		name = calleeIdent(f.X)
+	case *ast.IndexListExpr:
+		name = calleeIdent(f.X)
+	}
+	if function, ok := info.Uses[name].(*types.Func); ok && function.Pkg() != nil {
+		callee.name = function.Pkg().Name() + "." + function.Name()
+		if receiver := function.Type().(*types.Signature).Recv(); receiver != nil {
+			receiverType := receiver.Type()
+			if pointer, ok := receiverType.(*types.Pointer); ok {
+				receiverType = pointer.Elem()
+			}
+			if named, ok := receiverType.(*types.Named); ok {
+				callee.name = named.Obj().Pkg().Name() + "." + named.Obj().Name() + "." + function.Name()
+			} else if selection := info.Selections[call.Fun.(*ast.SelectorExpr)]; selection != nil {
+				callee.name = types.TypeString(selection.Recv(), (*types.Package).Name) + "." + function.Name()
+			}
+		}
+	}
+	return callee, true
+}
+
+// calleeIdent returns the identifier naming a function or method in f, pkg.F or x.Method, or nil
+func calleeIdent(expr ast.Expr) *ast.Ident {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e
+	case *ast.SelectorExpr:
+		return e.Sel
+	}
+	return nil
+}
+
+// errorCallee returns the name of a called function returning an error, its number of results
+// and the position of the error among them. Calls the type checker resolved are known from
+// calls, any other call to a module function from its declared results.
+func (a *Analysis) errorCallee(call *ast.CallExpr, calls map[*ast.CallExpr]errorCall, packageKey string, importMap map[string]string) (string, int, int, bool) {
+	if callee, typed := calls[call]; typed {
+		return callee.name, callee.results, callee.errorIndex, callee.errorIndex >= 0
+	}
+
+	// Functions and methods of the module, by their declared results
+	callee, exists := a.Nodes[resolveCallExpr(call, packageKey, importMap)]
+	if !exists || !isCallable(callee) {
+		return "", 0, 0, false
+	}
+	for i, result := range callee.Returns {
+		if result == "error" {
+			key := packageKeyOf(callee)
+			return key[strings.LastIndex(key, ":")+1:] + "." + graphLabel(callee), len(callee.Returns), i, true
+		}
+	}
+	return "", 0, 0, false
+}
+
+// recordIgnoredErrors records calls whose error result is dropped, either by using the call as a
+// statement, deferring it or launching it as a goroutine, or by assigning the error to _
+func (a *Analysis) recordIgnoredErrors(n ast.Node, site findingSite, function *CodeNode, calls map[*ast.CallExpr]errorCall, packageKey string, importMap map[string]string) {
+	var call *ast.CallExpr
+	kind := ""
+	switch node := n.(type) {
+	case *ast.ExprStmt:
+		call, _ = node.X.(*ast.CallExpr)
+		kind = "unchecked"
+	case *ast.DeferStmt:
+		call, kind = node.Call, "deferred"
+	case *ast.GoStmt:
+		call, kind = node.Call, "goroutine"
+	case *ast.AssignStmt:
+		if len(node.Rhs) != 1 || (node.Tok != token.ASSIGN && node.Tok != token.DEFINE) {
+			return
+		}
+		call, _ = node.Rhs[0].(*ast.CallExpr)
+		if call == nil {
+			return
+		}
+		name, results, errorIndex, ok := a.errorCallee(call, calls, packageKey, importMap)
+		if !ok || len(node.Lhs) != results {
+			return
+		}
+		if blank, isIdent := node.Lhs[errorIndex].(*ast.Ident); isIdent && blank.Name == "_" && !a.ignoredErrorAllowed(name) {
+			site.record("ignored errors", "assigned to _", name, name, function, call.Pos())
+		}
+		return
+	}
+	if call == nil {
+		return
+	}
+
+	if name, _, _, ok := a.errorCallee(call, calls, packageKey, importMap); ok && !a.ignoredErrorAllowed(name) {
+		site.record("ignored errors", kind, name, name, function, call.Pos())
+	}
+}
+
+// ignoredErrorAllowed reports whether a callee is on the allow-list of callees whose errors
+// may be ignored. Entries ending in * match any callee starting with the rest of the entry.
+func (o *Options) ignoredErrorAllowed(callee string) bool {
+	for _, allowed := range strings.Split(o.AllowIgnoredErrors, ",") {
+		allowed = strings.TrimSpace(allowed)
+		if allowed == "" {
+			continue
+		}
+		if prefix, isPattern := strings.CutSuffix(allowed, "*"); (isPattern && strings.HasPrefix(callee, prefix)) || callee == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// addIgnoredErrorsToOutput adds the calls whose errors are ignored, grouped by package and callee, to the report
+func (a *Analysis) addIgnoredErrorsToOutput(output *strings.Builder) {
+	ignored := a.findingsOf("ignored errors")
+
+	output.WriteString("\n## Ignored Errors\n\n")
+	if len(ignored) == 0 {
+		output.WriteString("*No ignored errors found.*\n")
+		return
+	}
+	output.WriteString(fmt.Sprintf("*%d calls drop an error result. Callees allowed with `-allow-ignored-errors` are left out.*\n", len(ignored)))
+
+	byPackage := make(map[string]map[string][]*Finding)
+	for _, finding := range ignored {
+		packageKey := packageKeyOf(finding.Function)
+		if byPackage[packageKey] == nil {
+			byPackage[packageKey] = make(map[string][]*Finding)
+		}
+		byPackage[packageKey][finding.Target] = append(byPackage[packageKey][finding.Target], finding)
+	}
+
+	packageKeys := make([]string, 0, len(byPackage))
+	for packageKey := range byPackage {
+		packageKeys = append(packageKeys, packageKey)
+	}
+	sort.Strings(packageKeys)
+
+	for _, packageKey := range packageKeys {
+		callees := make([]string, 0, len(byPackage[packageKey]))
+		for callee := range byPackage[packageKey] {
+			callees = append(callees, callee)
+		}
+		sort.Strings(callees)
+
+		output.WriteString(fmt.Sprintf("\n### Package `%s`\n\n", packageKey))
+		output.WriteString("| Callee | Sites | Where |\n")
+		output.WriteString("|--------|------:|-------|\n")
+		for _, callee := range callees {
+			var sites []string
+			for _, finding := range byPackage[packageKey][callee] {
+				sites = append(sites, fmt.Sprintf("`%s` %s (%s)", graphLabel(finding.Function), a.findingLink(finding), finding.Kind))
+			}
+			output.WriteString(fmt.Sprintf("| `%s` | %d | %s |\n", callee, len(sites), strings.Join(sites, ", ")))
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIgnoredErrors(t *testing.T) {
+	analysis := analyzeSource(t, map[string]string{
+		"store/store.go": `package store
+
+type DB struct{}
+
+func (db *DB) Save(v any) error { return nil }
+
+func (db *DB) Count() int { return 0 }
+
+func Open(path string) (*DB, error) { return &DB{}, nil }
+`,
+		"fx.go": `package fx
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"example.com/fx/store"
+)
+
+func run(w io.Writer) {
+	f, _ := os.Create("out")
+	defer f.Close()
+	w.Write(nil)
+
+	db, _ := store.Open("db")
+	db.Save(1)
+	_ = db.Count()
+	if err := db.Save(2); err != nil {
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("x")
+	go os.Remove("out")
+}
+`,
+	})
+
+	var got []string
+	for _, finding := range analysis.findingsOf("ignored errors") {
+		got = append(got, finding.Target+" "+finding.Kind)
+	}
+	want := []string{
+		"os.Create assigned to _",
+		"os.File.Close deferred",
+		"io.Writer.Write unchecked",
+		"store.Open assigned to _",
+		"store.DB.Save unchecked",
+		"strings.Builder.WriteString unchecked",
+		"os.Remove goroutine",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ignored errors %v, want %v", got, want)
+	}
+
+	analysis.AllowIgnoredErrors = "os.File.Close,strings.Builder.Write*"
+	if analysis.ignoredErrorAllowed("os.Create") || !analysis.ignoredErrorAllowed("os.File.Close") || !analysis.ignoredErrorAllowed("strings.Builder.WriteString") {
+		t.Errorf("allow-list %q not applied", analysis.AllowIgnoredErrors)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// JSONReport is the machine-readable form of the report, written with -json
+type JSONReport struct {
+	Module    string                    `json:"module"`
+	Symbols   []JSONSymbol              `json:"symbols"`
+	TypeUsage map[string]*JSONTypeUsage `json:"typeUsage"` // Keyed by the type's node key
+}
+
+// JSONSymbol is a function, method, type, constant or variable of the module
+type JSONSymbol struct {
+	Key       string   `json:"key"`
+	Name      string   `json:"name"`
+	Kind      string   `json:"kind"`
+	File      string   `json:"file"`
+	Line      int      `json:"line,omitempty"`
+	Receiver  string   `json:"receiver,omitempty"`
+	Signature string   `json:"signature,omitempty"`
+	Doc       string   `json:"doc,omitempty"`
+	Calls     []string `json:"calls,omitempty"`
+	CalledBy  []string `json:"calledBy,omitempty"`
+
+	// Kinds of side effects, e.g. "fs", performed directly and directly or through callees
+	SideEffects []string `json:"sideEffects,omitempty"`
+	Effects     []string `json:"effects,omitempty"`
+}
+
+// JSONTypeUsage lists the keys of the functions using a type, by the way they use it
+type JSONTypeUsage struct {
+	Params     []string `json:"params"`
+	Results    []string `json:"results"`
+	Constructs []string `json:"constructs"`
+	References []string `json:"references"`
+}
+
+// nodeKeys returns the keys of nodes, sorted
+func nodeKeys(nodes []*CodeNode) []string {
+	keys := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		keys = append(keys, node.Key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// buildJSONReport collects the symbols of the module and the type usage lookup
+func buildJSONReport(nodes map[string]*CodeNode, moduleInfo string) *JSONReport {
+	report := &JSONReport{
+		Module:    moduleInfo,
+		Symbols:   []JSONSymbol{},
+		TypeUsage: make(map[string]*JSONTypeUsage),
+	}
+
+	symbols := make([]*CodeNode, 0, len(nodes))
+	for _, node := range nodes {
+		symbols = append(symbols, node)
+	}
+	sortNodesByKey(symbols)
+
+	for _, node := range symbols {
+		symbol := JSONSymbol{
+			Key:       node.Key,
+			Name:      node.Name,
+			Kind:      node.Type,
+			File:      filepath.ToSlash(node.FilePath),
+			Line:      node.Line,
+			Receiver:  node.Receiver,
+			Signature: node.Signature,
+			Doc:       node.Doc,
+		}
+		if isCallable(node) {
+			symbol.Calls = nodeKeys(node.Calls)
+			symbol.CalledBy = nodeKeys(node.CalledBy)
+			symbol.SideEffects = node.SideEffects
+			symbol.Effects = node.Effects
+		}
+		report.Symbols = append(report.Symbols, symbol)
+
+		if isTypeNode(node) {
+			usage := typeUsage(node)
+			report.TypeUsage[node.Key] = &JSONTypeUsage{
+				Params:     nodeKeys(usage.Params),
+				Results:    nodeKeys(usage.Results),
+				Constructs: nodeKeys(usage.Constructs),
+				References: nodeKeys(usage.References),
+			}
+		}
+	}
+
+	return report
+}
+
+// writeJSONReport writes the JSON form of the report to a file
+func writeJSONReport(nodes map[string]*CodeNode, moduleInfo string, path string) error {
+	data, err := json.MarshalIndent(buildJSONReport(nodes, moduleInfo), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestJSONReportTypeUsage(t *testing.T) {
+	analysis := analyzeSource(t, map[string]string{"fx.go": `package fx
+
+type Server struct{ Addr string }
+
+func NewServer(addr string) *Server { return &Server{Addr: addr} }
+
+func Run(s *Server) error { return nil }
+
+func Addr(s any) string {
+	if server, ok := s.(*Server); ok {
+		return server.Addr
+	}
+	return ""
+}
+`})
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := writeJSONReport(analysis.Nodes, "example.com/fx", path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report JSONReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+
+	want := &JSONTypeUsage{
+		Params:     []string{".:fx:Run"},
+		Results:    []string{".:fx:NewServer"},
+		Constructs: []string{".:fx:NewServer"},
+		References: []string{".:fx:Addr"},
+	}
+	if got := report.TypeUsage[".:fx:Server"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("type usage of Server = %+v, want %+v", got, want)
+	}
+	if len(report.Symbols) != len(analysis.Nodes) {
+		t.Errorf("got %d symbols, want %d", len(report.Symbols), len(analysis.Nodes))
+	}
+}
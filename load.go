@@ -0,0 +1,242 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// loadedPackage is a package of the repository, parsed and type checked once and shared by
+// every analysis. The files of a directory's external test package form a package of their own.
+type loadedPackage struct {
+	Dir   string      // Directory relative to the repository
+	Name  string      // Package clause, e.g. "main" or "server_test"
+	Files []*ast.File // Files that parsed, in walk order
+	Paths []string    // Paths of Files relative to the repository
+	Info  *types.Info // Types, uses and selections found by the type checker
+
+	// Types is the package as the other packages of the module see it, so types from
+	// different packages can be compared
+	Types *types.Package
+}
+
+// loadPackages parses every Go file of the repository into the analysis's file set and type checks
+// each package. Module packages imported by others are checked from the parsed files, the standard
+// library and dependencies are imported from export data, or from source when there is none.
+// Packages that do not fully type check, e.g. when a dependency is missing, keep what could be typed.
+func (a *Analysis) loadPackages() {
+	a.Fset = token.NewFileSet()
+	a.Loaded = nil
+	byKey := make(map[string]*loadedPackage)
+	filepath.WalkDir(a.RepoPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if entry.IsDir() && (entry.Name() == "vendor" || entry.Name() == ".git") {
+			return filepath.SkipDir
+		}
+		if entry.IsDir() || !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+		file, err := parser.ParseFile(a.Fset, path, nil, parser.AllErrors|parser.ParseComments)
+		if err != nil {
+			return nil // Skip files with parsing errors
+		}
+		relPath, _ := filepath.Rel(a.RepoPath, path)
+		key := filepath.Dir(relPath) + ":" + file.Name.Name
+		pkg := byKey[key]
+		if pkg == nil {
+			pkg = &loadedPackage{Dir: filepath.Dir(relPath), Name: file.Name.Name}
+			byKey[key] = pkg
+			a.Loaded = append(a.Loaded, pkg)
+		}
+		pkg.Files = append(pkg.Files, file)
+		pkg.Paths = append(pkg.Paths, relPath)
+		return nil
+	})
+
+	checker := &packageChecker{
+		analysis: a,
+		external: importer.Default(),
+		source:   importer.ForCompiler(token.NewFileSet(), "source", nil),
+		checked:  make(map[string]*types.Package),
+		infos:    make(map[*loadedPackage]*types.Info),
+	}
+	for _, pkg := range a.Loaded {
+		// A package without test files is the one other packages import, so it is checked once
+		path := a.importPath(pkg.Dir)
+		if a.ModulePath != "" && !strings.HasSuffix(pkg.Name, "_test") && !hasTestFiles(pkg) {
+			checker.Import(path)
+			if info := checker.infos[pkg]; info != nil {
+				pkg.Info, pkg.Types = info, checker.checked[path]
+				continue
+			}
+		}
+		pkg.Info = newTypesInfo()
+		if strings.HasSuffix(pkg.Name, "_test") {
+			path += "_test"
+		}
+		var err error
+		if pkg.Types, err = checker.config().Check(path, a.Fset, pkg.Files, pkg.Info); err != nil {
+			log.Debug("Type checking %s: %v", pkg.Dir, err)
+		}
+	}
+
+	// Packages checked with their test files were checked again without them when imported
+	for _, pkg := range a.Loaded {
+		if imported := checker.checked[a.importPath(pkg.Dir)]; a.ModulePath != "" && imported != nil && !strings.HasSuffix(pkg.Name, "_test") {
+			pkg.Types = imported
+		}
+	}
+}
+
+// importPath returns the import path of a package directory of the module. Without a go.mod
+// the directory stands in for the import path.
+func (a *Analysis) importPath(dir string) string {
+	if a.ModulePath == "" {
+		return filepath.ToSlash(dir)
+	}
+	if dir == "." {
+		return a.ModulePath
+	}
+	return a.ModulePath + "/" + filepath.ToSlash(dir)
+}
+
+// objectKey returns the key of the node of a package-level function, method, type or value of
+// the module, e.g. "server:server:Server.Run", or "" for local objects and objects from elsewhere
+func (a *Analysis) objectKey(obj types.Object) string {
+	if obj == nil || obj.Pkg() == nil {
+		return ""
+	}
+	path := strings.TrimSuffix(obj.Pkg().Path(), "_test")
+	dir := filepath.FromSlash(path)
+	if a.ModulePath != "" {
+		if path == a.ModulePath {
+			dir = "."
+		} else if rest, inModule := strings.CutPrefix(path, a.ModulePath+"/"); inModule {
+			dir = filepath.FromSlash(rest)
+		} else {
+			return ""
+		}
+	}
+	key := dir + ":" + obj.Pkg().Name() + ":"
+
+	if function, ok := obj.(*types.Func); ok {
+		if recv := function.Origin().Type().(*types.Signature).Recv(); recv != nil {
+			named := namedType(recv.Type())
+			if named == nil {
+				return ""
+			}
+			return key + named.Obj().Name() + "." + function.Name()
+		}
+		return key + function.Name()
+	}
+	if obj.Parent() != obj.Pkg().Scope() {
+		return "" // Declared inside a function
+	}
+	return key + obj.Name()
+}
+
+// typeNode returns the node of the module's named type a type is or points to, or nil
+func (a *Analysis) typeNode(t types.Type) *CodeNode {
+	named := namedType(t)
+	if named == nil {
+		return nil
+	}
+	node, exists := a.Nodes[a.objectKey(named.Origin().Obj())]
+	if !exists || !isTypeNode(node) {
+		return nil
+	}
+	return node
+}
+
+// namedType returns the named type a type is or points to, or nil
+func namedType(t types.Type) *types.Named {
+	if pointer, ok := types.Unalias(t).(*types.Pointer); ok {
+		t = pointer.Elem()
+	}
+	named, _ := types.Unalias(t).(*types.Named)
+	return named
+}
+
+// hasTestFiles reports whether any file of a package is a _test.go file
+func hasTestFiles(pkg *loadedPackage) bool {
+	for _, path := range pkg.Paths {
+		if strings.HasSuffix(path, "_test.go") {
+			return true
+		}
+	}
+	return false
+}
+
+// newTypesInfo returns a types.Info recording what the analyses look up
+func newTypesInfo() *types.Info {
+	return &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+	}
+}
+
+// packageChecker imports packages while type checking: packages of the module are checked from
+// their parsed files, without their test files, and everything else comes from an importer
+type packageChecker struct {
+	analysis *Analysis
+	external types.Importer // Standard library and dependencies, from export data
+	source   types.Importer // Fallback for packages without export data
+	checked  map[string]*types.Package
+	infos    map[*loadedPackage]*types.Info // Types of the module packages checked for importing
+}
+
+// config returns the type checker configuration, which keeps going after errors to type as much as possible
+func (c *packageChecker) config() *types.Config {
+	return &types.Config{Importer: c, Error: func(error) {}}
+}
+
+// Import returns the package of an import path, type checking module packages on first use
+func (c *packageChecker) Import(path string) (*types.Package, error) {
+	if pkg, exists := c.checked[path]; exists {
+		if pkg == nil {
+			return nil, fmt.Errorf("import cycle through %s", path)
+		}
+		return pkg, nil
+	}
+
+	a := c.analysis
+	for _, loaded := range a.Loaded {
+		if a.ModulePath == "" || a.importPath(loaded.Dir) != path || strings.HasSuffix(loaded.Name, "_test") {
+			continue
+		}
+		var files []*ast.File
+		for i, file := range loaded.Files {
+			if !strings.HasSuffix(loaded.Paths[i], "_test.go") {
+				files = append(files, file)
+			}
+		}
+		info := newTypesInfo()
+		c.infos[loaded] = info
+		c.checked[path] = nil // Marks the package as being checked
+		pkg, err := c.config().Check(path, a.Fset, files, info)
+		if err != nil {
+			log.Debug("Type checking %s: %v", loaded.Dir, err)
+		}
+		c.checked[path] = pkg
+		return pkg, nil
+	}
+
+	pkg, err := c.external.Import(path)
+	if err != nil {
+		pkg, err = c.source.Import(path)
+	}
+	if err == nil {
+		c.checked[path] = pkg
+	}
+	return pkg, err
+}
@@ -6,9 +6,11 @@ import (
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"html"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -22,14 +24,60 @@ var log Logger
 
 // CodeNode represents a node in the code structure tree
 type CodeNode struct {
-	Name       string
-	Type       string // "package", "function", "method", "interface", etc.
-	FilePath   string
-	Children   []*CodeNode
-	CalledBy   []*CodeNode
-	Calls      []*CodeNode
-	Implements string
-	Receiver   string // For methods
+	Key             string // Key of the node in Analysis.Nodes
+	Name            string
+	Type            string // "package", "function", "method", "interface", etc.
+	FilePath        string
+	Line            int // Start line of the declaration, 0 when unknown
+	Column          int
+	EndLine         int
+	Children        []*CodeNode
+	CalledBy        []*CodeNode
+	Calls           []*CodeNode
+	Implements      []string     // Interfaces in the module a type has all the methods of
+	Fields          []*FieldInfo // Struct fields, and embedded interfaces of interfaces
+	Methods         []string     // Method signatures of interfaces, e.g. "Name() string"
+	Receiver        string       // For methods
+	PointerReceiver bool         // For methods declared on *Receiver
+	MethodFiles     []string     // For types whose methods are spread over several files
+	Signature       string       // Declaration as written, for functions and methods
+	Doc             string       // First sentence of the doc comment
+	TypeParams      string       // Type parameter list of generic functions and types, e.g. "[T any]"
+	Instantiations  []string     // Explicit instantiations of a generic function or type, e.g. "Set[int]"
+	ValueType       string       // Declared or carried over type of constants and variables
+	Value           string       // Initial value of constants and variables as written
+	EnumType        string       // Named type of constants declared in an iota block
+	WrittenBy       []*CodeNode  // Functions assigning to a package-level variable
+	Usage           *TypeUsage   // Functions taking, returning, constructing or referencing a type
+	Returns         []string     // Result types of functions as written
+	Variadic        string       // Element type of a final variadic parameter as written, e.g. Option for opts ...Option
+	OptionFor       string       // Type configured by a functional option type, e.g. Server for func(*Server)
+	Role            string       // "constructor", "options" or "option" when nested under the type it creates or configures
+	References      []*CodeNode  // Functions used as values without being called, e.g. callbacks
+	ReferencedBy    []*CodeNode
+	IndirectCalls   []string    // Calls through func-typed variables, parameters and fields, e.g. "handler"
+	Goroutines      []*CodeNode // Functions launched with go statements
+	SideEffects     []string    // Kinds of side effects performed directly, e.g. "fs" or "network"
+	Effects         []string    // Kinds of side effects performed directly or by any callee
+	Cycle           int         // Recursive call cycle the node is part of, 0 if none
+
+	// Call graph centrality, for functions and methods
+	FanIn       int // Distinct callers
+	FanOut      int // Distinct callees
+	PageRank    float64
+	Betweenness float64
+}
+
+// FieldInfo describes a struct field, or a type embedded in a struct or interface
+type FieldInfo struct {
+	Name     string // Type name for embedded fields
+	Type     string
+	Tag      string
+	Exported bool
+	Embedded bool
+
+	ReadBy    []*CodeNode // Functions reading the field
+	WrittenBy []*CodeNode // Functions assigning to the field
 }
 
 // TreeNode represents a file or directory in the tree
@@ -45,61 +93,198 @@ type TreeRenderer interface {
 	GetName() string
 }
 
-// Global map to store all functions for easier lookup during relationship building
-var allNodes map[string]*CodeNode
+// Options holds the report settings chosen on the command line
+type Options struct {
+	GraphFocus         string // Package or symbol the call graph is centred on
+	GraphDepth         int    // Maximum number of hops from the focused nodes, 0 for unlimited
+	GraphMaxNodes      int    // Maximum number of nodes in a single Mermaid diagram
+	GraphLevel         string // "function", or "package"/"directory" to add a collapsed call graph
+	GraphColor         string // Centrality metric used to colour call graph nodes, empty for none
+	RepoPath           string // Repository being analyzed
+	OutputFile         string // Report file, source links are relative to its directory
+	LinkTemplate       string // URL template for source links, empty for relative paths
+	ShortSigs          bool   // Leave parameter names out of signatures
+	JSONSchemas        string // Comma separated structs to write JSON Schema documents for
+	SchemaDir          string // Directory the JSON Schema documents are written to
+	JSONOutput         string // File the JSON form of the report is written to, empty for none
+	ModulePath         string // Module path from go.mod
+	GitRoot            string // Root of the git repository containing RepoPath
+	Revision           string // Commit checked out in the git repository
+	AllowIgnoredErrors string // Comma separated callees whose errors may be ignored, a trailing * matches a prefix
+	ExitAllowed        string // Comma separated directories allowed to call os.Exit and log.Fatal, empty to allow any
+}
+
+// Analysis holds the options of a run and what it found in the module, from the analyses
+// through to the functions writing the report
+type Analysis struct {
+	Options
+	Nodes        map[string]*CodeNode // Functions, methods, types and values by key
+	Packages     map[string]string    // Package directories of the module to package keys, used to resolve imports
+	Findings     []*Finding           // Findings of every file, in the order they are found
+	Fset         *token.FileSet       // Positions of the parsed files
+	Loaded       []*loadedPackage     // Parsed and type checked packages, shared by the analyses
+	DirRoot      *TreeNode
+	CodeRoot     *CodeNode
+	MainPackages []string
+	CallCounts   map[string]int
+	Cycles       [][]*CodeNode
+	Stats        map[string]int
+}
 
 func main() {
 	// Parsing command line flags
 	repoPath := flag.String("path", ".", "Path to the Go repository to analyze")
 	outputFile := flag.String("output", "code_structure.md", "Output file path")
 	verbose := flag.Bool("verbose", false, "Enable verbose logging")
+	shortSignatures := flag.Bool("short-signatures", false, "Leave parameter and result names out of function signatures")
+	jsonSchemas := flag.String("json-schema", "", "Comma separated structs to write JSON Schema documents for")
+	schemaDir := flag.String("schema-dir", ".", "Directory to write JSON Schema documents to")
+	jsonOutput := flag.String("json", "", "File to write the JSON form of the report to")
+	graphFocus := flag.String("graph-focus", "", "Package or symbol to centre the call graph on")
+	graphDepth := flag.Int("graph-depth", 0, "Maximum call distance from the focused nodes (0 for unlimited)")
+	graphMaxNodes := flag.Int("graph-max-nodes", 100, "Maximum number of nodes per call graph diagram before splitting by package")
+	graphLevel := flag.String("graph-level", "function", "Call graph level: function, package or directory")
+	linkTemplate := flag.String("link-template", "", "Source link URL template, e.g. https://github.com/{repo}/blob/{rev}/{path}#L{line}")
+	graphColor := flag.String("graph-color", "", "Colour call graph nodes by centrality: pagerank, betweenness, fan-in or fan-out")
+	exitAllowed := flag.String("exit-allowed", "", "Comma separated directories allowed to call os.Exit and log.Fatal, e.g. cmd; calls elsewhere fail the run")
+	allowIgnoredErrors := flag.String("allow-ignored-errors", "fmt.Print*,fmt.Fprint*,strings.Builder.Write*,bytes.Buffer.Write*", "Comma separated callees whose errors may be ignored, a trailing * matches a prefix")
 
 	flag.Parse()
 
 	log = Logger{Verbose: *verbose}
+	options := Options{
+		GraphFocus:         *graphFocus,
+		GraphDepth:         *graphDepth,
+		GraphMaxNodes:      *graphMaxNodes,
+		GraphLevel:         *graphLevel,
+		GraphColor:         *graphColor,
+		RepoPath:           *repoPath,
+		OutputFile:         *outputFile,
+		LinkTemplate:       *linkTemplate,
+		ShortSigs:          *shortSignatures,
+		JSONSchemas:        *jsonSchemas,
+		SchemaDir:          *schemaDir,
+		JSONOutput:         *jsonOutput,
+		AllowIgnoredErrors: *allowIgnoredErrors,
+		ExitAllowed:        *exitAllowed,
+	}
+
+	if options.GraphLevel != "function" && options.GraphLevel != "package" && options.GraphLevel != "directory" {
+		fmt.Printf("Invalid graph level %q, expected function, package or directory\n", options.GraphLevel)
+		os.Exit(1)
+	}
+	switch options.GraphColor {
+	case "", "pagerank", "betweenness", "fan-in", "fan-out":
+	default:
+		fmt.Printf("Invalid graph color %q, expected pagerank, betweenness, fan-in or fan-out\n", options.GraphColor)
+		os.Exit(1)
+	}
 
 	log.Info("Starting code structure analysis for: %s", *repoPath)
 
-	allNodes = make(map[string]*CodeNode)
+	a, err := analyze(options)
+	if err != nil {
+		fmt.Printf("Error building project structure: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Step 5: Generate and output the report
+	log.Info("Creating report structure...")
+	treeOutput := a.generateStructureDoc()
+
+	err = os.WriteFile(*outputFile, []byte(treeOutput), 0644)
+	if err != nil {
+		fmt.Printf("Error writing to file: %v\n", err)
+		os.Exit(1)
+	}
+
+	log.Info(fmt.Sprintf("Code structure saved to %s", *outputFile))
+
+	if a.JSONOutput != "" {
+		log.Info("Writing JSON report...")
+		err = writeJSONReport(a.Nodes, a.ModulePath, a.JSONOutput)
+		if err != nil {
+			fmt.Printf("Error writing JSON report: %v\n", err)
+			os.Exit(1)
+		}
+		log.Info(fmt.Sprintf("JSON report saved to %s", a.JSONOutput))
+	}
+
+	if a.JSONSchemas != "" {
+		log.Info("Writing JSON Schema documents...")
+		err = writeJSONSchemas(a.Nodes, strings.Split(a.JSONSchemas, ","), a.SchemaDir)
+		if err != nil {
+			fmt.Printf("Error writing JSON Schema: %v\n", err)
+			os.Exit(1)
+		}
+	}
 
-	stats := generateProjectStats(*repoPath)
+	if violations := a.exitViolations(); len(violations) > 0 {
+		fmt.Printf("%d calls to os.Exit or log.Fatal outside %s:\n", len(violations), a.ExitAllowed)
+		for _, violation := range violations {
+			fmt.Printf("  %s:%d %s in %s\n", filepath.ToSlash(violation.FilePath), violation.Line, violation.Detail, graphLabel(violation.Function))
+		}
+		os.Exit(1)
+	}
+}
+
+// analyze runs every analysis over the repository at options.RepoPath
+func analyze(options Options) (*Analysis, error) {
+	a := &Analysis{
+		Options:  options,
+		Nodes:    make(map[string]*CodeNode),
+		Packages: make(map[string]string),
+	}
+	a.Stats = generateProjectStats(a.RepoPath)
 
 	log.Info("Identifying module info...")
-	moduleInfo, err := findModuleInfo(*repoPath)
+	moduleInfo, err := findModuleInfo(a.RepoPath)
 	if err != nil {
 		fmt.Printf("Error finding module info: %v\n", err)
 	}
+	a.ModulePath = moduleInfo
+
+	if a.LinkTemplate != "" {
+		log.Info("Detecting git revision...")
+		a.GitRoot, err = findGitRoot(a.RepoPath)
+		if err == nil {
+			a.Revision, err = detectRevision(a.GitRoot)
+		}
+		if err != nil {
+			fmt.Printf("Error detecting git revision: %v\n", err)
+		}
+	}
+
+	log.Info("Parsing and type checking packages...")
+	a.loadPackages()
 
 	// Step 2: Build project structure
 	log.Info("Building project structure...")
-
-	dirRoot, codeRoot, err := buildProjectStructure(*repoPath)
+	a.DirRoot, a.CodeRoot, err = a.buildProjectStructure()
 	if err != nil {
-		fmt.Printf("Error building project structure: %v\n", err)
-		os.Exit(1)
+		return nil, err
 	}
 
 	log.Info("Finding and identifying main packages (entry points)...")
-	mainPackages, err := findMainPackages(*repoPath)
+	a.MainPackages, err = findMainPackages(a.RepoPath)
 	if err != nil {
 		fmt.Printf("Error finding main packages: %v\n", err)
 	}
 
 	// Step 4: Analyze function calls and build relationships
 	log.Info("Analysing function calls...")
-	callCounts := analyzeFunctionCalls(*repoPath)
+	a.CallCounts = a.analyzeFunctionCalls()
 
-	// Step 5: Generate and output the report
-	log.Info("Creating report structure...")
-	treeOutput := generateStructureDoc(codeRoot, dirRoot, moduleInfo, mainPackages, callCounts, stats)
+	log.Info("Classifying side effects...")
+	propagateSideEffects(a.Nodes)
 
-	err = os.WriteFile(*outputFile, []byte(treeOutput), 0644)
-	if err != nil {
-		fmt.Printf("Error writing to file: %v\n", err)
-		os.Exit(1)
-	}
+	log.Info("Detecting recursion and call cycles...")
+	a.Cycles = detectCallCycles(a.Nodes)
 
-	log.Info(fmt.Sprintf("Code structure saved to %s", *outputFile))
+	log.Info("Computing call graph centrality...")
+	computeCentrality(a.Nodes)
+
+	return a, nil
 }
 
 func generateProjectStats(repoPath string) map[string]int {
@@ -111,6 +296,8 @@ func generateProjectStats(repoPath string) map[string]int {
 		"methods":     0,
 		"structs":     0,
 		"interfaces":  0,
+		"constants":   0,
+		"variables":   0,
 		"loc":         0, // lines of code
 		"directories": 0,
 		"testFiles":   0,
@@ -189,13 +376,25 @@ func generateProjectStats(repoPath string) map[string]int {
 						}
 					case *ast.GenDecl:
 						for _, spec := range d.Specs {
-							if typeSpec, ok := spec.(*ast.TypeSpec); ok {
-								switch typeSpec.Type.(type) {
+							switch spec := spec.(type) {
+							case *ast.TypeSpec:
+								switch spec.Type.(type) {
 								case *ast.StructType:
 									stats["structs"]++
 								case *ast.InterfaceType:
 									stats["interfaces"]++
 								}
+							case *ast.ValueSpec:
+								for _, name := range spec.Names {
+									if name.Name == "_" {
+										continue
+									}
+									if d.Tok == token.CONST {
+										stats["constants"]++
+									} else {
+										stats["variables"]++
+									}
+								}
 							}
 						}
 					}
@@ -228,7 +427,9 @@ func (l *Logger) Error(format string, args ...interface{}) {
 	fmt.Printf("ERROR: "+format+"\n", args...)
 }
 
-func (n *CodeNode) RenderNode(output *strings.Builder, prefix string, isLast bool) {
+// renderCodeNode adds a node of the code tree and its children to the report as HTML lines of
+// a <pre> block, so the location of each symbol links to its source
+func (a *Analysis) renderCodeNode(output *strings.Builder, n *CodeNode, prefix string, isLast bool) {
 	if n.Name == "" {
 		return
 	}
@@ -243,37 +444,65 @@ func (n *CodeNode) RenderNode(output *strings.Builder, prefix string, isLast boo
 	}
 
 	// Format based on node type
+	var line strings.Builder
 	switch n.Type {
 	case "repository":
-		output.WriteString(fmt.Sprintf("%s/\n", n.Name))
+		line.WriteString(fmt.Sprintf("%s/\n", n.Name))
 	case "package":
-		output.WriteString(fmt.Sprintf("%s (%s)\n", n.Name, n.FilePath))
-	case "function":
-		output.WriteString(fmt.Sprintf("func %s()\n", n.Name))
+		line.WriteString(fmt.Sprintf("%s (%s)\n", n.Name, n.FilePath))
+	case "function", "closure":
+		if n.Signature != "" {
+			line.WriteString(n.Signature)
+		} else {
+			line.WriteString(fmt.Sprintf("func %s()", n.Name))
+		}
 	case "method":
-		output.WriteString(fmt.Sprintf("func (%s) %s()\n", n.Receiver, n.Name))
+		if n.Signature != "" {
+			line.WriteString(n.Signature)
+		} else if n.PointerReceiver {
+			line.WriteString(fmt.Sprintf("func (*%s) %s()", n.Receiver, n.Name))
+		} else {
+			line.WriteString(fmt.Sprintf("func (%s) %s()", n.Receiver, n.Name))
+		}
+	case "constant", "variable":
+		keyword := "var"
+		if n.Type == "constant" {
+			keyword = "const"
+		}
+		line.WriteString(strings.TrimSpace(fmt.Sprintf("%s %s %s", keyword, n.Name, n.ValueType)))
 	case "struct":
-		output.WriteString(fmt.Sprintf("struct %s\n", n.Name))
+		line.WriteString(fmt.Sprintf("struct %s%s", n.Name, n.TypeParams))
 	case "interface":
-		output.WriteString(fmt.Sprintf("interface %s\n", n.Name))
+		line.WriteString(fmt.Sprintf("interface %s%s", n.Name, n.TypeParams))
 	default:
-		output.WriteString(fmt.Sprintf("%s (%s)\n", n.Name, n.Type))
+		line.WriteString(fmt.Sprintf("%s%s (%s)", n.Name, n.TypeParams, n.Type))
 	}
 
-	// Processing children
-	renderChildren(output, n.GetChildren(), nodePrefix)
-}
+	// Symbols are followed by their location and doc summary
+	if n.Type != "repository" && n.Type != "package" {
+		output.WriteString(html.EscapeString(line.String()))
+		line.Reset()
+		output.WriteString(a.positionLink(n))
+		if n.Role != "" {
+			line.WriteString(fmt.Sprintf("  [%s]", n.Role))
+		}
+		if len(n.Effects) > 0 {
+			line.WriteString(fmt.Sprintf("  [effects: %s]", strings.Join(n.Effects, ", ")))
+		}
+		if len(n.MethodFiles) > 0 {
+			line.WriteString(fmt.Sprintf("  [methods in %s]", strings.Join(n.MethodFiles, ", ")))
+		}
+		if n.Doc != "" {
+			line.WriteString("  // " + n.Doc)
+		}
+		line.WriteString("\n")
+	}
+	output.WriteString(html.EscapeString(line.String()))
 
-func (n *CodeNode) GetChildren() []TreeRenderer {
-	result := make([]TreeRenderer, len(n.Children))
+	// Processing children
 	for i, child := range n.Children {
-		result[i] = child
+		a.renderCodeNode(output, child, nodePrefix, i == len(n.Children)-1)
 	}
-	return result
-}
-
-func (n *CodeNode) GetName() string {
-	return n.Name
 }
 
 func (n *TreeNode) RenderNode(output *strings.Builder, prefix string, isLast bool) {
@@ -323,7 +552,9 @@ func renderTree(output *strings.Builder, node TreeRenderer, prefix string, isLas
 	node.RenderNode(output, prefix, isLast)
 }
 
-func buildProjectStructure(repoPath string) (*TreeNode, *CodeNode, error) {
+func (a *Analysis) buildProjectStructure() (*TreeNode, *CodeNode, error) {
+	repoPath := a.RepoPath
+
 	// first start by creating root nodes
 	repoName := filepath.Base(repoPath)
 	dirRoot := &TreeNode{
@@ -339,6 +570,14 @@ func buildProjectStructure(repoPath string) (*TreeNode, *CodeNode, error) {
 	// Initialize package map to avoid duplicates
 	packages := make(map[string]*CodeNode)
 
+	// Go files as parsed when loading the packages
+	parsed := make(map[string]*ast.File)
+	for _, pkg := range a.Loaded {
+		for i, file := range pkg.Files {
+			parsed[pkg.Paths[i]] = file
+		}
+	}
+
 	// Walk through the repository once
 	err := filepath.WalkDir(repoPath, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
@@ -370,8 +609,8 @@ func buildProjectStructure(repoPath string) (*TreeNode, *CodeNode, error) {
 		addNodeToTree(dirRoot, relPath, d.IsDir())
 
 		// Process Go files for code structure
-		if !d.IsDir() && strings.HasSuffix(path, ".go") {
-			processGoFile(path, relPath, codeRoot, packages)
+		if file, exists := parsed[relPath]; exists && !d.IsDir() {
+			a.processGoFile(file, relPath, codeRoot, packages)
 		}
 
 		return nil
@@ -380,16 +619,19 @@ func buildProjectStructure(repoPath string) (*TreeNode, *CodeNode, error) {
 	// Sort directory tree
 	sortTree(dirRoot)
 
+	// Nest methods, enum values, constructors and options under their types
+	for _, packageNode := range codeRoot.Children {
+		groupMethodsByReceiver(packageNode)
+		groupEnumValues(packageNode)
+		groupConstructors(packageNode)
+	}
+	a.detectImplementations(codeRoot)
+
 	return dirRoot, codeRoot, err
 }
 
-func processGoFile(path, relPath string, codeRoot *CodeNode, packages map[string]*CodeNode) {
-	// Parse Go file
-	fset := token.NewFileSet()
-	file, err := parser.ParseFile(fset, path, nil, 0)
-	if err != nil {
-		return // Skip files with parsing errors
-	}
+func (a *Analysis) processGoFile(file *ast.File, relPath string, codeRoot *CodeNode, packages map[string]*CodeNode) {
+	fset := a.Fset
 
 	// Get package name and create package node if it doesn't exist
 	packageName := file.Name.Name
@@ -407,13 +649,16 @@ func processGoFile(path, relPath string, codeRoot *CodeNode, packages map[string
 		}
 		packages[packageKey] = packageNode
 		codeRoot.Children = append(codeRoot.Children, packageNode)
+		if !strings.HasSuffix(packageName, "_test") {
+			a.Packages[packagePath] = packageKey
+		}
 	}
 
 	// Process declarations in the file
 	for _, decl := range file.Decls {
 		switch d := decl.(type) {
 		case *ast.FuncDecl:
-			functionNode := processFunction(d, relPath)
+			functionNode := processFunction(d, relPath, fset, a.ShortSigs)
 			if functionNode != nil {
 				packageNode.Children = append(packageNode.Children, functionNode)
 
@@ -422,62 +667,123 @@ func processGoFile(path, relPath string, codeRoot *CodeNode, packages map[string
 				if functionNode.Receiver != "" {
 					nodeName = packageKey + ":" + functionNode.Receiver + "." + functionNode.Name
 				}
-				allNodes[nodeName] = functionNode
+				functionNode.Key = nodeName
+				a.Nodes[nodeName] = functionNode
 			}
 		case *ast.GenDecl:
 			for _, spec := range d.Specs {
 				if typeSpec, ok := spec.(*ast.TypeSpec); ok {
-					typeNode := processType(typeSpec, relPath)
+					typeNode := processType(typeSpec, relPath, fset)
 					if typeNode != nil {
+						// A lone type declaration keeps its doc comment on the GenDecl
+						if typeNode.Doc == "" && len(d.Specs) == 1 {
+							typeNode.Doc = firstSentence(d.Doc.Text())
+						}
 						packageNode.Children = append(packageNode.Children, typeNode)
 
 						// Add to global map
 						nodeName := packageKey + ":" + typeNode.Name
-						allNodes[nodeName] = typeNode
+						typeNode.Key = nodeName
+						a.Nodes[nodeName] = typeNode
 					}
 				}
 			}
+
+			// Package-level constants and variables
+			if d.Tok == token.CONST || d.Tok == token.VAR {
+				for _, valueNode := range processValues(d, relPath, fset) {
+					packageNode.Children = append(packageNode.Children, valueNode)
+
+					nodeName := packageKey + ":" + valueNode.Name
+					valueNode.Key = nodeName
+					a.Nodes[nodeName] = valueNode
+				}
+			}
 		}
 	}
 }
 
 // Update processFunction to not repeat filepath.Rel operations
-func processFunction(funcDecl *ast.FuncDecl, relPath string) *CodeNode {
+func processFunction(funcDecl *ast.FuncDecl, relPath string, fset *token.FileSet, shortSignatures bool) *CodeNode {
 	// Create function node
 	functionNode := &CodeNode{
-		Name:     funcDecl.Name.Name,
-		Type:     "function",
-		FilePath: relPath,
+		Name:      funcDecl.Name.Name,
+		Type:      "function",
+		FilePath:  relPath,
+		Doc:       firstSentence(funcDecl.Doc.Text()),
+		Signature: funcSignature(funcDecl, shortSignatures),
 	}
+	setPosition(functionNode, fset, funcDecl)
+	functionNode.Returns = resultTypes(funcDecl.Type)
+	functionNode.Variadic = variadicType(funcDecl.Type)
 
 	// Check if it's a method
 	if funcDecl.Recv != nil && len(funcDecl.Recv.List) > 0 {
 		functionNode.Type = "method"
 
-		// Get receiver type
-		if expr, ok := funcDecl.Recv.List[0].Type.(*ast.StarExpr); ok {
-			// Pointer receiver
-			if ident, ok := expr.X.(*ast.Ident); ok {
-				functionNode.Receiver = ident.Name
-			}
-		} else if ident, ok := funcDecl.Recv.List[0].Type.(*ast.Ident); ok {
-			// Value receiver
-			functionNode.Receiver = ident.Name
-		}
+		// Get receiver type, including generic receivers like *Set[T]
+		receiverType := funcDecl.Recv.List[0].Type
+		_, functionNode.PointerReceiver = receiverType.(*ast.StarExpr)
+		functionNode.Receiver = getReceiverTypeName(receiverType)
+	} else if funcDecl.Type.TypeParams != nil {
+		functionNode.TypeParams = "[" + fieldListString(funcDecl.Type.TypeParams, false) + "]"
 	}
 
 	return functionNode
 }
 
+// groupMethodsByReceiver moves the methods of a package under their receiver types,
+// and records the files of types whose methods are declared in more than one file
+func groupMethodsByReceiver(packageNode *CodeNode) {
+	typeNodes := make(map[string]*CodeNode)
+	for _, child := range packageNode.Children {
+		if child.Type != "function" && child.Type != "method" {
+			typeNodes[child.Name] = child
+		}
+	}
+
+	var children []*CodeNode
+	for _, child := range packageNode.Children {
+		if typeNode, exists := typeNodes[child.Receiver]; exists && child.Type == "method" {
+			typeNode.Children = append(typeNode.Children, child)
+			continue
+		}
+		children = append(children, child)
+	}
+	packageNode.Children = children
+
+	for _, typeNode := range typeNodes {
+		files := make(map[string]bool)
+		for _, child := range typeNode.Children {
+			if child.Type == "method" {
+				files[filepath.Base(child.FilePath)] = true
+			}
+		}
+		if len(files) < 2 {
+			continue
+		}
+
+		typeNode.MethodFiles = nil
+		for file := range files {
+			typeNode.MethodFiles = append(typeNode.MethodFiles, file)
+		}
+		sort.Strings(typeNode.MethodFiles)
+	}
+}
+
 // Update processType to not repeat filepath.Rel operations
-func processType(typeSpec *ast.TypeSpec, relPath string) *CodeNode {
-	// Determine type kind
+func processType(typeSpec *ast.TypeSpec, relPath string, fset *token.FileSet) *CodeNode {
+	// Determine type kind, and collect the members of structs and interfaces
 	var typeKind string
-	switch typeSpec.Type.(type) {
+	var fields []*FieldInfo
+	var methods []string
+	switch t := typeSpec.Type.(type) {
 	case *ast.StructType:
 		typeKind = "struct"
+		fields = structFields(t)
 	case *ast.InterfaceType:
 		typeKind = "interface"
+		fields, methods = interfaceMembers(t)
 	default:
 		typeKind = "type"
 	}
@@ -487,7 +793,17 @@ func processType(typeSpec *ast.TypeSpec, relPath string) *CodeNode {
 		Name:     typeSpec.Name.Name,
 		Type:     typeKind,
 		FilePath: relPath,
+		Doc:      firstSentence(typeSpec.Doc.Text()),
+		Fields:   fields,
+		Methods:  methods,
+	}
+	if typeSpec.TypeParams != nil {
+		typeNode.TypeParams = "[" + fieldListString(typeSpec.TypeParams, false) + "]"
+	}
+	if funcType, ok := typeSpec.Type.(*ast.FuncType); ok {
+		typeNode.OptionFor = optionTarget(funcType)
 	}
+	setPosition(typeNode, fset, typeSpec)
 
 	return typeNode
 }
@@ -563,85 +879,188 @@ func findMainPackages(repoPath string) ([]string, error) {
 // analyzeFunctionCalls performs static analysis to build a graph of function calls
 // It uses Go's AST to accurately identify function and method calls across the codebase
 // Returns a map of the most frequently called functions, sorted by call count
-// Takes the repository path and returns an error if parsing fails
-func analyzeFunctionCalls(repoPath string) map[string]int {
+// Calls into module packages are resolved with the module path
+func (a *Analysis) analyzeFunctionCalls() map[string]int {
 	// Track call counts for functions
 	callCounts := make(map[string]int)
 
-	// Walk through the repository
-	filepath.Walk(repoPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// Skip non-Go files
-		if !info.IsDir() && !strings.HasSuffix(path, ".go") {
-			return nil
-		}
-
-		// Skip vendor and .git directories
-		if info.IsDir() && (info.Name() == "vendor" || info.Name() == ".git") {
-			return filepath.SkipDir
-		}
-
-		// Process Go files to find function calls
-		if !info.IsDir() && strings.HasSuffix(path, ".go") {
-			fset := token.NewFileSet()
-			file, err := parser.ParseFile(fset, path, nil, parser.AllErrors)
-			if err != nil {
-				return nil // Skip files with parsing errors
-			}
-
-			// Extract package info
-			packageName := file.Name.Name
-			packagePath, _ := filepath.Rel(repoPath, filepath.Dir(path))
-			packageKey := packagePath + ":" + packageName
+	for _, pkg := range a.Loaded {
+		calls := typedCalls(pkg)
+		for i, file := range pkg.Files {
+			fset, relPath := a.Fset, pkg.Paths[i]
+			packageKey := pkg.Dir + ":" + pkg.Name
 
 			// Map to store imports for resolving function calls
 			importMap := buildImportMap(file)
+			a.resolveLocalImports(importMap)
 
 			// Track scope and current function
 			var currentFunc *ast.FuncDecl
-			var currentFuncKey string
+			var funcStack []*CodeNode             // The enclosing function, then the closures nested in it
+			var nodeStack []ast.Node              // Nodes being visited, to pop funcStack when leaving a function
+			var typeParams map[string]bool        // Type parameters in scope, which are not concrete instantiations
+			var hasContext bool                   // Whether the current function was handed a context.Context
+			skipIdents := make(map[ast.Node]bool) // Field names and qualified names that are not types or functions
+			calledFuncs := make(map[ast.Expr]bool)
+			closureVars := make(map[*ast.Object]*CodeNode)   // Variables holding a closure, e.g. visit := func() {...}
+			goCalls := make(map[*ast.CallExpr]bool)          // Calls made by go statements
+			written := make(map[*ast.SelectorExpr]bool)      // Field selectors assigned to, which are not reads
+			reflectCalls := make(map[*ast.SelectorExpr]bool) // Calls into reflect and unsafe, which are not type references
+			site := findingSite{analysis: a, fset: fset, relPath: relPath}
+			a.recordDirectives(file, site, packageKey)
 
 			// Visit all nodes in the AST
 			ast.Inspect(file, func(n ast.Node) bool {
+				if n == nil {
+					switch nodeStack[len(nodeStack)-1].(type) {
+					case *ast.FuncDecl, *ast.FuncLit:
+						funcStack = funcStack[:len(funcStack)-1]
+					}
+					nodeStack = nodeStack[:len(nodeStack)-1]
+					return true
+				}
+				nodeStack = append(nodeStack, n)
+
+				var currentNode *CodeNode
+				if len(funcStack) > 0 {
+					currentNode = funcStack[len(funcStack)-1]
+				}
+				recordReflection(pkg.Info, n, site, currentNode, packageKey, reflectCalls)
+
+				// Types, fields and functions used inside function bodies
+				if currentNode != nil && currentFunc.Body != nil && n.Pos() >= currentFunc.Body.Pos() && n.End() <= currentFunc.Body.End() {
+					a.recordBodyTypeUsage(n, currentNode, packageKey, importMap, skipIdents)
+
+					a.recordFieldWrites(pkg.Info, n, currentNode, written)
+					a.recordConcurrency(n, site, currentNode, pkg.Info)
+					a.recordErrorHandling(n, site, currentNode, packageKey, importMap)
+					a.recordIgnoredErrors(n, site, currentNode, calls, packageKey, importMap)
+					recordExits(n, site, currentNode, importMap)
+					recordSideEffects(pkg.Info, n, currentNode)
+					if selector, ok := n.(*ast.SelectorExpr); ok {
+						a.recordFieldRead(pkg.Info, selector, currentNode, written)
+						skipIdents[selector.Sel] = true
+					}
+
+					// Functions and method values used without being called
+					switch n.(type) {
+					case *ast.Ident, *ast.SelectorExpr:
+						if !skipIdents[n] && !calledFuncs[n.(ast.Expr)] {
+							if referenced := a.resolveFunctionValue(pkg.Info, n.(ast.Expr)); referenced != nil {
+								addReference(currentNode, referenced)
+							}
+						}
+					}
+				}
+
 				switch node := n.(type) {
 				case *ast.FuncDecl:
 					// Track which function we're currently in
 					currentFunc = node
-					currentFuncKey = buildFunctionKey(packageKey, node)
+					typeParams = funcTypeParams(node)
+					functionNode := a.Nodes[buildFunctionKey(packageKey, node)]
+					hasContext = contextParamIndex(node.Type, importMap) >= 0
+					if functionNode != nil {
+						a.recordSignatureTypes(node, functionNode, packageKey, importMap)
+						checkContextParam(node, functionNode, site, importMap)
+					}
+					funcStack = append(funcStack, functionNode)
 					return true
 
+				case *ast.FuncLit:
+					// Closures become child nodes of the function they are declared in
+					var closure *CodeNode
+					if currentNode != nil {
+						closure = a.newClosure(currentNode, node, relPath, fset)
+
+						// Called on the spot, stored in a variable to be called later, or used as a value
+						parent := nodeStack[len(nodeStack)-2]
+						if call, ok := parent.(*ast.CallExpr); ok && call.Fun == node {
+							addCall(currentNode, closure)
+							if goCalls[call] {
+								recordGoroutine(site, currentNode, call, closure)
+							}
+						} else if variable := closureVariable(parent, node); variable != nil {
+							closureVars[variable] = closure
+						} else {
+							addReference(currentNode, closure)
+						}
+					}
+					funcStack = append(funcStack, closure)
+
+				case *ast.GenDecl:
+					// Declarations inside a function keep the function's type parameters in scope
+					if currentNode == nil {
+						typeParams = make(map[string]bool)
+					}
+
+				case *ast.TypeSpec:
+					addTypeParams(typeParams, node.TypeParams)
+
+				case *ast.IndexExpr, *ast.IndexListExpr:
+					a.recordInstantiation(node.(ast.Expr), packageKey, importMap, typeParams)
+
+				case *ast.AssignStmt, *ast.IncDecStmt, *ast.UnaryExpr:
+					if currentNode != nil {
+						a.recordGlobalWrites(pkg.Info, node, currentNode)
+					}
+
 				case *ast.CallExpr:
 					// Skip if we're not in a function
-					if currentFunc == nil {
+					if currentNode == nil {
 						return true
 					}
+					a.recordGlobalWrites(pkg.Info, node, currentNode)
+					calledFuncs[node.Fun] = true
+					if hasContext {
+						recordContextCall(node, site, currentNode, importMap)
+					}
 
 					// Resolve the called function
+					var calledNode *CodeNode
 					calledFuncKey := resolveCallExpr(node, packageKey, importMap)
-					if calledFuncKey != "" {
-						// Update call count
-						callCounts[calledFuncKey]++
+					if ident, ok := node.Fun.(*ast.Ident); ok && ident.Obj != nil && closureVars[ident.Obj] != nil {
+						// Calls through variables holding a closure go to the closure
+						calledNode, calledFuncKey = closureVars[ident.Obj], ""
+					} else if existing, exists := a.Nodes[calledFuncKey]; exists {
+						calledNode = existing
+					} else if calledNode = a.resolveFunctionValue(pkg.Info, node.Fun); calledNode != nil {
+						// Method calls on variables of known type
+						calledFuncKey = calledNode.Key
+					}
 
-						// Establish the relationship between functions
-						if currentNode, exists := allNodes[currentFuncKey]; exists {
-							if calledNode, exists := allNodes[calledFuncKey]; exists {
-								// Check if this relationship already exists
-								if !functionCallExists(currentNode, calledNode) {
-									currentNode.Calls = append(currentNode.Calls, calledNode)
-									calledNode.CalledBy = append(calledNode.CalledBy, currentNode)
-								}
+					// Goroutines launching a function literal are recorded once its closure exists
+					if _, literal := node.Fun.(*ast.FuncLit); goCalls[node] && !literal {
+						recordGoroutine(site, currentNode, node, calledNode)
+					}
+
+					// Calls through other func-typed variables have no static target
+					if calledNode == nil || calledNode.Type == "variable" {
+						if callee := indirectCallee(pkg.Info, node); callee != "" {
+							if !slices.Contains(currentNode.IndirectCalls, callee) {
+								currentNode.IndirectCalls = append(currentNode.IndirectCalls, callee)
 							}
+							return true
 						}
 					}
+
+					// Update call count
+					if calledFuncKey != "" {
+						callCounts[calledFuncKey]++
+					}
+
+					// Establish the relationship between functions
+					if calledNode != nil {
+						addCall(currentNode, calledNode)
+					}
+
+				case *ast.GoStmt:
+					goCalls[node.Call] = true
 				}
 				return true
 			})
 		}
-		return nil
-	})
+	}
 
 	// Find most called functions
 	type FunctionCallCount struct {
@@ -666,8 +1085,8 @@ func analyzeFunctionCalls(repoPath string) map[string]int {
 }
 
 // generateStructureTree creates the final output as a tree
-func generateStructureDoc(codeRoot *CodeNode, dirRoot *TreeNode, moduleInfo string,
-	mainPackages []string, callCounts map[string]int, stats map[string]int) string {
+func (a *Analysis) generateStructureDoc() string {
+	stats, moduleInfo, mainPackages := a.Stats, a.ModulePath, a.MainPackages
 	var output strings.Builder
 
 	// Add header with improved formatting
@@ -684,6 +1103,8 @@ func generateStructureDoc(codeRoot *CodeNode, dirRoot *TreeNode, moduleInfo stri
 	output.WriteString(fmt.Sprintf("| Methods | %d |\n", stats["methods"]))
 	output.WriteString(fmt.Sprintf("| Structs | %d |\n", stats["structs"]))
 	output.WriteString(fmt.Sprintf("| Interfaces | %d |\n", stats["interfaces"]))
+	output.WriteString(fmt.Sprintf("| Constants | %d |\n", stats["constants"]))
+	output.WriteString(fmt.Sprintf("| Variables | %d |\n", stats["variables"]))
 	output.WriteString(fmt.Sprintf("| Test Files | %d |\n", stats["testFiles"]))
 	output.WriteString(fmt.Sprintf("| Directories | %d |\n", stats["directories"]))
 	output.WriteString(fmt.Sprintf("| Total Lines of Code | %d |\n", stats["loc"]))
@@ -708,71 +1129,56 @@ func generateStructureDoc(codeRoot *CodeNode, dirRoot *TreeNode, moduleInfo stri
 	// Add directory structure with collapsible section
 	output.WriteString("Directory Structure\n\n")
 	output.WriteString("```bash\n")
-	renderTree(&output, dirRoot, "", true)
+	renderTree(&output, a.DirRoot, "", true)
 	output.WriteString("```\n</details>\n\n")
 
 	// Add code structure with collapsible section
 	output.WriteString("Code Structure\n\n")
-	output.WriteString("```bash\n")
-	renderTree(&output, codeRoot, "", true)
-	output.WriteString("```\n</details>\n\n")
+	output.WriteString("<pre>\n")
+	a.renderCodeNode(&output, a.CodeRoot, "", true)
+	output.WriteString("</pre>\n</details>\n\n")
 
 	// Add function call graph with improved formatting
-	output.WriteString("## Function Call Graph\n\n")
-	output.WriteString("View Function Call Graph\n\n")
-	output.WriteString("```mermaid\ngraph TD\n")
-	renderFunctionCallGraph(&output, allNodes)
-	output.WriteString("```\n</details>\n\n")
+	if a.GraphLevel != "function" {
+		// The collapsed graph comes first, with the full function graph available on demand
+		output.WriteString(fmt.Sprintf("## %s Call Graph\n\n", strings.ToUpper(a.GraphLevel[:1])+a.GraphLevel[1:]))
+		a.renderPackageCallGraph(&output)
+		output.WriteString("\n")
 
-	addMostCalledFunctionsToOutput(&output, callCounts)
+		output.WriteString("## Function Call Graph\n\n")
+		output.WriteString("<details>\n<summary>View Function Call Graph</summary>\n\n")
+	} else {
+		output.WriteString("## Function Call Graph\n\n")
+		output.WriteString("View Function Call Graph\n\n")
+	}
+	a.renderFunctionCallGraph(&output)
+	output.WriteString("</details>\n\n")
+
+	a.addMostCalledFunctionsToOutput(&output)
+	a.addCriticalFunctionsToOutput(&output)
+	a.addCallCyclesToOutput(&output)
+	a.addCallDepthToOutput(&output)
+	a.addFunctionLengthsToOutput(&output)
+	a.addGenericsToOutput(&output)
+	a.addClassDiagramsToOutput(&output)
+	a.addStructTagsToOutput(&output)
+	a.addEnumsToOutput(&output)
+	a.addGlobalStateToOutput(&output)
+	a.addTypeUsageToOutput(&output)
+	a.addFieldAccessToOutput(&output)
+	a.addConcurrencyToOutput(&output)
+	a.addContextToOutput(&output)
+	a.addErrorsToOutput(&output)
+	a.addIgnoredErrorsToOutput(&output)
+	a.addExitsToOutput(&output)
+	a.addSideEffectsToOutput(&output)
+	a.addReflectionToOutput(&output)
 	// Add footer
 	output.WriteString("\n---\n*This document was automatically generated by the Go Code Structure Analyzer*\n")
 
 	return output.String()
 }
 
-// renderFunctionCallGraph renders the function call graph in Mermaid format
-func renderFunctionCallGraph(output *strings.Builder, nodes map[string]*CodeNode) {
-	// Add a node for each function
-	for key, node := range nodes {
-		if node.Type == "function" || node.Type == "method" {
-			// Clean key for Mermaid
-			cleanKey := strings.ReplaceAll(key, ":", "_")
-			cleanKey = strings.ReplaceAll(cleanKey, ".", "_")
-			cleanKey = strings.ReplaceAll(cleanKey, "/", "_")
-
-			// Node definition
-			var label string
-			if node.Type == "method" {
-				label = fmt.Sprintf("%s.%s", node.Receiver, node.Name)
-			} else {
-				label = node.Name
-			}
-			output.WriteString(fmt.Sprintf("    %s[\"%s\"]\n", cleanKey, label))
-
-			// Edges for function calls
-			for _, calledNode := range node.Calls {
-				// Find the key for the called node
-				var calledKey string
-				for k, n := range nodes {
-					if n == calledNode {
-						calledKey = k
-						break
-					}
-				}
-
-				if calledKey != "" {
-					cleanCalledKey := strings.ReplaceAll(calledKey, ":", "_")
-					cleanCalledKey = strings.ReplaceAll(cleanCalledKey, ".", "_")
-					cleanCalledKey = strings.ReplaceAll(cleanCalledKey, "/", "_")
-
-					output.WriteString(fmt.Sprintf("    %s --> %s\n", cleanKey, cleanCalledKey))
-				}
-			}
-		}
-	}
-}
-
 // addNodeToTree adds a node to the tree based on its path
 func addNodeToTree(root *TreeNode, path string, isDir bool) {
 	parts := strings.Split(path, string(os.PathSeparator))
@@ -845,6 +1251,30 @@ func buildImportMap(file *ast.File) map[string]string {
 	return importMap
 }
 
+// resolveLocalImports rewrites imports of packages inside the module to their package keys,
+// so calls across packages of the repository resolve to nodes of the analysis
+func (a *Analysis) resolveLocalImports(importMap map[string]string) {
+	modulePath := a.ModulePath
+	if modulePath == "" {
+		return
+	}
+
+	for name, importPath := range importMap {
+		var dir string
+		if importPath == modulePath {
+			dir = "."
+		} else if strings.HasPrefix(importPath, modulePath+"/") {
+			dir = filepath.FromSlash(strings.TrimPrefix(importPath, modulePath+"/"))
+		} else {
+			continue
+		}
+
+		if packageKey, exists := a.Packages[dir]; exists {
+			importMap[name] = packageKey
+		}
+	}
+}
+
 // buildFunctionKey creates a unique key for a function
 func buildFunctionKey(packageKey string, funcDecl *ast.FuncDecl) string {
 	if funcDecl.Recv != nil && len(funcDecl.Recv.List) > 0 {
@@ -860,11 +1290,15 @@ func buildFunctionKey(packageKey string, funcDecl *ast.FuncDecl) string {
 func getReceiverTypeName(expr ast.Expr) string {
 	switch t := expr.(type) {
 	case *ast.StarExpr:
-		if ident, ok := t.X.(*ast.Ident); ok {
-			return ident.Name
-		}
+		return getReceiverTypeName(t.X)
 	case *ast.Ident:
 		return t.Name
+	case *ast.IndexExpr:
+		// Generic receiver with one type parameter, e.g. Set[T]
+		return getReceiverTypeName(t.X)
+	case *ast.IndexListExpr:
+		// Generic receiver with several type parameters, e.g. Map[K, V]
+		return getReceiverTypeName(t.X)
 	}
 	return ""
 }
@@ -874,7 +1308,13 @@ func getReceiverTypeName(expr ast.Expr) string {
 // Returns function identifier and true if successfully resolved, empty string and false otherwise
 // Takes the AST CallExpr node, current package info, and import aliases as inputs
 func resolveCallExpr(callExpr *ast.CallExpr, packageKey string, importMap map[string]string) string {
-	switch fun := callExpr.Fun.(type) {
+	return resolveExpr(callExpr.Fun, packageKey, importMap)
+}
+
+// resolveExpr returns the node key an identifier or selector expression refers to,
+// looking through explicit instantiations of generic functions and types
+func resolveExpr(expr ast.Expr, packageKey string, importMap map[string]string) string {
+	switch fun := expr.(type) {
 	case *ast.Ident:
 		// Direct function call in the same package
 		return packageKey + ":" + fun.Name
@@ -891,6 +1331,17 @@ func resolveCallExpr(callExpr *ast.CallExpr, packageKey string, importMap map[st
 			// This could be a method call on a variable
 			return packageKey + ":" + x.Name + "." + fun.Sel.Name
 		}
+
+	case *ast.IndexExpr:
+		// Generic instantiation with one type argument, e.g. Map[int]
+		return resolveExpr(fun.X, packageKey, importMap)
+
+	case *ast.IndexListExpr:
+		// Generic instantiation with several type arguments, e.g. Map[int, string]
+		return resolveExpr(fun.X, packageKey, importMap)
+
+	case *ast.ParenExpr:
+		return resolveExpr(fun.X, packageKey, importMap)
 	}
 
 	return "" // Unknown call type
@@ -907,9 +1358,9 @@ func functionCallExists(caller *CodeNode, callee *CodeNode) bool {
 }
 
 // Add a new function to enrich the output with most called functions
-func addMostCalledFunctionsToOutput(output *strings.Builder, callCounts map[string]int) {
+func (a *Analysis) addMostCalledFunctionsToOutput(output *strings.Builder) {
 	output.WriteString("\n## Most Called Functions\n\n")
-	output.WriteString("| Function | Type | File | Call Count |\n")
+	output.WriteString("| Function | Type | Location | Call Count |\n")
 	output.WriteString("|----------|------|------|------------|\n")
 
 	type FunctionCallCount struct {
@@ -918,7 +1369,7 @@ func addMostCalledFunctionsToOutput(output *strings.Builder, callCounts map[stri
 	}
 
 	var mostCalled []FunctionCallCount
-	for funcKey, count := range callCounts {
+	for funcKey, count := range a.CallCounts {
 		mostCalled = append(mostCalled, FunctionCallCount{
 			Key:   funcKey,
 			Count: count,
@@ -932,7 +1383,7 @@ func addMostCalledFunctionsToOutput(output *strings.Builder, callCounts map[stri
 
 	count := 0
 	for _, fn := range mostCalled {
-		if node, exists := allNodes[fn.Key]; exists {
+		if node, exists := a.Nodes[fn.Key]; exists {
 			var displayName string
 			if node.Type == "method" {
 				displayName = fmt.Sprintf("(%s) %s", node.Receiver, node.Name)
@@ -941,7 +1392,7 @@ func addMostCalledFunctionsToOutput(output *strings.Builder, callCounts map[stri
 			}
 
 			output.WriteString(fmt.Sprintf("| %s | %s | %s | %d |\n",
-				displayName, node.Type, node.FilePath, fn.Count))
+				displayName, node.Type, a.sourceLink(node), fn.Count))
 			count++
 		}
 	}
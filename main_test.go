@@ -0,0 +1,40 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// analyzeSource writes a module example.com/fx made of the given files to a temporary
+// directory and runs the analysis on it the way main does
+func analyzeSource(t *testing.T, files map[string]string) *Analysis {
+	t.Helper()
+	dir := t.TempDir()
+	files["go.mod"] = "module example.com/fx\n\ngo 1.22\n"
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	analysis, err := analyze(Options{RepoPath: dir, OutputFile: filepath.Join(dir, "code_structure.md")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return analysis
+}
+
+// nodeByKey returns the node of a key in the analysis, failing the test when there is none
+func nodeByKey(t *testing.T, analysis *Analysis, key string) *CodeNode {
+	t.Helper()
+	node, exists := analysis.Nodes[key]
+	if !exists {
+		t.Fatalf("no node %s", key)
+	}
+	return node
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// reportedDirectives lists the //go: compiler directives reported, those that change how the
+// compiler or linker treats a function or bypass its checks. Directives such as //go:build,
+// //go:generate, //go:embed and //go:debug are left out.
+var reportedDirectives = map[string]bool{
+	"linkname": true, "nosplit": true, "noescape": true, "norace": true, "noinline": true,
+	"nocheckptr": true, "uintptrescapes": true, "nowritebarrier": true, "nowritebarrierrec": true,
+	"yeswritebarrierrec": true, "systemstack": true, "wasmimport": true, "wasmexport": true,
+	"cgo_import_dynamic": true, "cgo_import_static": true, "cgo_export_dynamic": true,
+	"cgo_export_static": true, "cgo_unsafe_args": true,
+}
+
+// recordDirectives records the compiler directives of a file listed in reportedDirectives,
+// such as //go:linkname or //go:nosplit
+func (a *Analysis) recordDirectives(file *ast.File, site findingSite, packageKey string) {
+	// Directives in a function's doc comment apply to that function
+	documented := make(map[*ast.CommentGroup]*CodeNode)
+	for _, decl := range file.Decls {
+		if funcDecl, ok := decl.(*ast.FuncDecl); ok && funcDecl.Doc != nil {
+			documented[funcDecl.Doc] = a.Nodes[buildFunctionKey(packageKey, funcDecl)]
+		}
+	}
+
+	for _, group := range file.Comments {
+		for _, comment := range group.List {
+			directive, isDirective := strings.CutPrefix(comment.Text, "//go:")
+			if !isDirective {
+				continue
+			}
+			name, _, _ := strings.Cut(directive, " ")
+			if !reportedDirectives[name] {
+				continue
+			}
+			site.record("reflection", "directive", comment.Text, packageKey, documented[group], comment.Pos())
+		}
+	}
+}
+
+// recordReflection records every use of the reflect and unsafe packages inside functions or at
+// package level, either as a call, such as reflect.TypeOf(v) or the conversion unsafe.Pointer(p),
+// or as a reference to one of their types, such as a reflect.Value parameter. Calls are added to
+// called, so the selector of the call is not counted again as a reference.
+func recordReflection(info *types.Info, n ast.Node, site findingSite, function *CodeNode, packageKey string, called map[*ast.SelectorExpr]bool) {
+	use := "type"
+	selector, ok := n.(*ast.SelectorExpr)
+	if call, isCall := n.(*ast.CallExpr); isCall {
+		selector, ok = ast.Unparen(call.Fun).(*ast.SelectorExpr)
+		use = "call"
+	}
+	if !ok || called[selector] {
+		return
+	}
+	x, isIdent := selector.X.(*ast.Ident)
+	if !isIdent {
+		return
+	}
+	if _, isPackage := info.Uses[x].(*types.PkgName); !isPackage {
+		return // Methods, e.g. v.Field(0) on a reflect.Value, are covered by the call that made the value
+	}
+
+	var importPath string
+	switch obj := info.Uses[selector.Sel].(type) {
+	case *types.Func:
+		importPath, use = obj.Pkg().Path(), "call" // Functions used as values count as calls
+	case *types.Builtin:
+		importPath, use = "unsafe", "call" // unsafe.Sizeof and the like
+	case *types.TypeName:
+		if obj.Pkg() != nil {
+			importPath = obj.Pkg().Path()
+		}
+	}
+	if importPath != "reflect" && importPath != "unsafe" {
+		return
+	}
+	if use == "call" {
+		called[selector] = true
+	}
+	site.record("reflection", importPath+" "+use, types.ExprString(selector), packageKey, function, selector.Pos())
+}
+
+// addReflectionToOutput adds the uses of reflect and unsafe, and the compiler directives, to the report, grouped by package
+func (a *Analysis) addReflectionToOutput(output *strings.Builder) {
+	uses := a.findingsOf("reflection")
+
+	output.WriteString("\n## Reflection and Unsafe\n\n")
+	if len(uses) == 0 {
+		output.WriteString("*No uses of reflect, unsafe or //go: directives found.*\n")
+		return
+	}
+
+	byPackage := make(map[string][]*Finding)
+	for _, finding := range uses {
+		byPackage[finding.Target] = append(byPackage[finding.Target], finding)
+	}
+	packageKeys := make([]string, 0, len(byPackage))
+	for packageKey := range byPackage {
+		packageKeys = append(packageKeys, packageKey)
+	}
+	sort.Strings(packageKeys)
+	for _, packageKey := range packageKeys {
+		sort.SliceStable(byPackage[packageKey], func(i, j int) bool {
+			a, b := byPackage[packageKey][i], byPackage[packageKey][j]
+			return a.FilePath < b.FilePath || (a.FilePath == b.FilePath && a.Line < b.Line)
+		})
+	}
+
+	output.WriteString("*Calls (including conversions such as `unsafe.Pointer(p)`) and references to the types of `reflect` and `unsafe` are counted separately.*\n\n")
+	output.WriteString("| Package | reflect calls | reflect types | unsafe calls | unsafe types | Directives |\n")
+	output.WriteString("|---------|--------------:|--------------:|-------------:|-------------:|-----------:|\n")
+	for _, packageKey := range packageKeys {
+		counts := make(map[string]int)
+		for _, finding := range byPackage[packageKey] {
+			counts[finding.Kind]++
+		}
+		output.WriteString(fmt.Sprintf("| %s | %d | %d | %d | %d | %d |\n", packageKey,
+			counts["reflect call"], counts["reflect type"], counts["unsafe call"], counts["unsafe type"], counts["directive"]))
+	}
+
+	for _, packageKey := range packageKeys {
+		output.WriteString(fmt.Sprintf("\n### Package `%s`\n\n", packageKey))
+		output.WriteString("| Kind | Use | Function | Location |\n")
+		output.WriteString("|------|-----|----------|----------|\n")
+		for _, finding := range byPackage[packageKey] {
+			function := "-"
+			if finding.Function != nil {
+				function = "`" + graphLabel(finding.Function) + "`"
+			}
+			output.WriteString(fmt.Sprintf("| %s | `%s` | %s | %s |\n", finding.Kind, finding.Detail, function, a.findingLink(finding)))
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReflection(t *testing.T) {
+	analysis := analyzeSource(t, map[string]string{"fx.go": `package fx
+
+import (
+	"reflect"
+	"unsafe"
+	_ "embed"
+)
+
+//go:generate stringer -type=Kind
+//go:embed fx.go
+var source string
+
+var typeOf = reflect.TypeOf
+
+func fields(v any) []reflect.StructField {
+	value := reflect.ValueOf(v)
+	var out []reflect.StructField
+	for i := range value.NumField() {
+		out = append(out, value.Type().Field(i))
+	}
+	return out
+}
+
+//go:noinline
+//go:nosplit
+func size(p *int) uintptr {
+	_ = (*int)(unsafe.Pointer(p))
+	return unsafe.Sizeof(*p)
+}
+
+//go:linkname nanotime runtime.nanotime
+func nanotime() int64
+
+//go:debug panicnil=1
+func untracked() {}
+`})
+
+	var got []string
+	for _, finding := range analysis.findingsOf("reflection") {
+		function := "-"
+		if finding.Function != nil {
+			function = graphLabel(finding.Function)
+		}
+		got = append(got, function+" "+finding.Kind+" "+finding.Detail)
+	}
+	want := []string{
+		"size directive //go:noinline",
+		"size directive //go:nosplit",
+		"nanotime directive //go:linkname nanotime runtime.nanotime",
+		"- reflect call reflect.TypeOf",
+		"fields reflect type reflect.StructField",
+		"fields reflect call reflect.ValueOf",
+		"fields reflect type reflect.StructField",
+		"size unsafe call unsafe.Pointer",
+		"size unsafe call unsafe.Sizeof",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("reflection findings = %q, want %q", got, want)
+	}
+}
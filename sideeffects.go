@@ -0,0 +1,242 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"slices"
+	"strings"
+)
+
+// sideEffectKinds lists the kinds of side effects in the order they are shown
+var sideEffectKinds = []string{"fs", "network", "process", "env", "time", "random", "stdio"}
+
+// sideEffectPackages maps standard library packages to the kind of side effect their functions have
+var sideEffectPackages = map[string]string{
+	"os":           "fs",
+	"io/fs":        "fs",
+	"io/ioutil":    "fs",
+	"net":          "network",
+	"net/http":     "network",
+	"net/rpc":      "network",
+	"net/smtp":     "network",
+	"os/exec":      "process",
+	"os/signal":    "process",
+	"syscall":      "process",
+	"math/rand":    "random",
+	"math/rand/v2": "random",
+	"crypto/rand":  "random",
+	"log":          "stdio",
+	"log/slog":     "stdio",
+}
+
+// sideEffectFunctions maps standard library functions to the kind of side effect they have when
+// it differs from their package, or when their package has none
+var sideEffectFunctions = map[string]string{
+	"os.Getenv": "env", "os.LookupEnv": "env", "os.Setenv": "env", "os.Unsetenv": "env",
+	"os.Environ": "env", "os.ExpandEnv": "env", "os.Clearenv": "env",
+	"os.Exit": "process", "os.Getpid": "process", "os.Getppid": "process", "os.FindProcess": "process",
+	"os.StartProcess": "process", "os.Executable": "process", "os.Hostname": "process",
+	"log.Fatal": "process", "log.Fatalf": "process", "log.Fatalln": "process",
+	"fmt.Print": "stdio", "fmt.Printf": "stdio", "fmt.Println": "stdio",
+	"fmt.Fprint": "stdio", "fmt.Fprintf": "stdio", "fmt.Fprintln": "stdio",
+	"fmt.Scan": "stdio", "fmt.Scanf": "stdio", "fmt.Scanln": "stdio",
+	"fmt.Fscan": "stdio", "fmt.Fscanf": "stdio", "fmt.Fscanln": "stdio",
+	"go/parser.ParseFile": "fs", "go/parser.ParseDir": "fs",
+	"path/filepath.Walk": "fs", "path/filepath.WalkDir": "fs", "path/filepath.Glob": "fs", "path/filepath.EvalSymlinks": "fs",
+	"time.Now": "time", "time.Since": "time", "time.Until": "time", "time.Sleep": "time",
+	"time.After": "time", "time.AfterFunc": "time", "time.Tick": "time", "time.NewTimer": "time",
+	"time.NewTicker": "time",
+}
+
+// sideEffectOf returns the kind of side effect of calling a standard library function or method, or "".
+// Functions of sideEffectFunctions have the kind listed. Other functions and methods of a package in
+// sideEffectPackages have the package's kind, unless their signature shows they only build or
+// inspect values, see valueOnly.
+func sideEffectOf(callee *types.Func) string {
+	path := callee.Pkg().Path()
+	if callee.Type().(*types.Signature).Recv() == nil {
+		if kind, listed := sideEffectFunctions[path+"."+callee.Name()]; listed {
+			return kind
+		}
+	}
+	kind := sideEffectPackages[path]
+	if kind == "" || valueOnly(callee, kind) {
+		return ""
+	}
+	return kind
+}
+
+// valueOnly reports whether a function or method of a package with side effects only builds or
+// inspects values, judging by its signature: it returns a type of its own package without an error,
+// as constructors such as log.New and exec.Command or builders such as (*slog.Logger).With do,
+// takes an error without returning one, as os.IsNotExist does, is a method of a type that is
+// neither a struct nor an interface, such as http.Header.Get or os.FileMode.IsDir, or is a getter
+// such as (*os.File).Name. Getters still count for random, as reading a source is the effect.
+func valueOnly(callee *types.Func, kind string) bool {
+	signature := callee.Type().(*types.Signature)
+	params, results := signature.Params(), signature.Results()
+	returnsError := false
+	for i := range results.Len() {
+		returnsError = returnsError || isErrorInterface(results.At(i).Type())
+	}
+	if returnsError {
+		return false
+	}
+
+	if results.Len() > 0 {
+		if named := namedType(results.At(0).Type()); named != nil && named.Obj().Pkg() == callee.Pkg() {
+			return true
+		}
+	}
+
+	if recv := signature.Recv(); recv != nil {
+		receiverType := recv.Type()
+		if pointer, ok := receiverType.(*types.Pointer); ok {
+			receiverType = pointer.Elem()
+		}
+		switch receiverType.Underlying().(type) {
+		case *types.Struct, *types.Interface:
+		default:
+			return true
+		}
+		return kind != "random" && params.Len() == 0 && results.Len() > 0
+	}
+
+	for i := range params.Len() {
+		if isErrorInterface(params.At(i).Type()) {
+			return true
+		}
+	}
+	return false
+}
+
+// isErrorInterface reports whether a type is the predeclared error interface
+func isErrorInterface(t types.Type) bool {
+	return types.Identical(t, types.Universe.Lookup("error").Type())
+}
+
+// addSideEffect adds a kind of side effect to a list, keeping the order of sideEffectKinds
+func addSideEffect(kinds []string, kind string) []string {
+	if slices.Contains(kinds, kind) {
+		return kinds
+	}
+	kinds = append(kinds, kind)
+	slices.SortFunc(kinds, func(a, b string) int {
+		return slices.Index(sideEffectKinds, a) - slices.Index(sideEffectKinds, b)
+	})
+	return kinds
+}
+
+// recordSideEffects records the side effects of the standard library functions and methods a
+// function calls, such as os.ReadFile, (*os.File).Write or (*http.Client).Do, as found by the type checker
+func recordSideEffects(info *types.Info, n ast.Node, function *CodeNode) {
+	call, ok := n.(*ast.CallExpr)
+	if !ok || function == nil {
+		return
+	}
+	callee, ok := info.Uses[calleeIdent(ast.Unparen(call.Fun))].(*types.Func)
+	if !ok || callee.Pkg() == nil {
+		return
+	}
+	kind := sideEffectOf(callee)
+	if callee.Pkg().Path() == "go/parser" && callee.Name() == "ParseFile" && len(call.Args) > 2 && !info.Types[call.Args[2]].IsNil() {
+		kind = "" // Parses the source given rather than reading the file
+	}
+	if kind != "" {
+		function.SideEffects = addSideEffect(function.SideEffects, kind)
+	}
+}
+
+// propagateSideEffects sets the side effects of every function to its own and those of the
+// functions it calls, launches or passes as values, until no function gains a new kind
+func propagateSideEffects(nodes map[string]*CodeNode) {
+	functions := selectGraphNodes(nodes, "", 0)
+	for _, node := range functions {
+		node.Effects = slices.Clone(node.SideEffects)
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, node := range functions {
+			for _, callee := range append(slices.Clone(node.Calls), node.References...) {
+				for _, kind := range callee.Effects {
+					if !slices.Contains(node.Effects, kind) {
+						node.Effects = addSideEffect(node.Effects, kind)
+						changed = true
+					}
+				}
+			}
+		}
+	}
+}
+
+// addSideEffectsToOutput adds the side effects of every function, and the functions without any detected, to the report
+func (a *Analysis) addSideEffectsToOutput(output *strings.Builder) {
+	functions := selectGraphNodes(a.Nodes, "", 0)
+
+	var impure, undetected []*CodeNode
+	counts := make(map[string]int)
+	for _, node := range functions {
+		if len(node.Effects) == 0 {
+			undetected = append(undetected, node)
+			continue
+		}
+		impure = append(impure, node)
+		for _, kind := range node.Effects {
+			counts[kind]++
+		}
+	}
+
+	output.WriteString("\n## Side Effects\n\n")
+	if len(impure) == 0 {
+		output.WriteString("*No functions with filesystem, network, process, environment, time, randomness or standard I/O side effects found.*\n")
+		return
+	}
+
+	var summary []string
+	for _, kind := range sideEffectKinds {
+		if counts[kind] > 0 {
+			summary = append(summary, fmt.Sprintf("%s: %d", kind, counts[kind]))
+		}
+	}
+	output.WriteString(fmt.Sprintf("*%d of %d functions have side effects, directly or through their callees (%s). Methods of standard library types are not classified.*\n\n", len(impure), len(functions), strings.Join(summary, ", ")))
+
+	output.WriteString("| Function | Location | Direct | Through Callees |\n")
+	output.WriteString("|----------|----------|--------|-----------------|\n")
+	for _, node := range impure {
+		var inherited []string
+		for _, kind := range node.Effects {
+			if !slices.Contains(node.SideEffects, kind) {
+				inherited = append(inherited, kind)
+			}
+		}
+		direct := strings.Join(node.SideEffects, ", ")
+		if direct == "" {
+			direct = "-"
+		}
+		through := strings.Join(inherited, ", ")
+		if through == "" {
+			through = "-"
+		}
+		output.WriteString(fmt.Sprintf("| `%s` | %s | %s | %s |\n", graphLabel(node), a.sourceLink(node), direct, through))
+	}
+
+	if len(undetected) == 0 {
+		return
+	}
+	output.WriteString("\n### Functions With No Detected Side Effects\n\n")
+	output.WriteString("*Only calls to the standard library functions classified above are detected: these functions may still have side effects through methods, such as those of `*os.File`, interfaces or function values.*\n\n")
+	byPackage := make(map[string][]string)
+	var packageKeys []string
+	for _, node := range undetected {
+		packageKey := packageKeyOf(node)
+		if byPackage[packageKey] == nil {
+			packageKeys = append(packageKeys, packageKey)
+		}
+		byPackage[packageKey] = append(byPackage[packageKey], "`"+graphLabel(node)+"`")
+	}
+	for _, packageKey := range packageKeys {
+		output.WriteString(fmt.Sprintf("- **%s**: %s\n", packageKey, strings.Join(byPackage[packageKey], ", ")))
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSideEffects(t *testing.T) {
+	analysis := analyzeSource(t, map[string]string{"fx.go": `package fx
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+type Logger struct{}
+
+func (l *Logger) Info(format string, args ...any) { fmt.Printf(format, args...) }
+
+func warn(msg string) { log.Println(msg) }
+
+func parseFile(path string) { parser.ParseFile(token.NewFileSet(), path, nil, 0) }
+
+func parseSource(src string) { parser.ParseFile(token.NewFileSet(), "x.go", src, 0) }
+
+func run(l *Logger) {
+	l.Info("start")
+	parseFile(os.Args[1])
+}
+
+func upper(s string) string { return strings.ToUpper(s) }
+
+func save(f *os.File, data []byte) { f.Write(data) }
+
+func fetch(client *http.Client, req *http.Request) { client.Do(req) }
+
+func build() *exec.Cmd {
+	logger := log.New(os.Stderr, "", 0)
+	_ = logger
+	return exec.Command("true")
+}
+
+func inspect(err error, f *os.File, h http.Header) string {
+	if os.IsNotExist(err) {
+		return f.Name()
+	}
+	return h.Get("Accept")
+}
+
+func start(cmd *exec.Cmd) { cmd.Run() }
+`})
+
+	tests := []struct {
+		key     string
+		direct  []string
+		effects []string
+	}{
+		{".:fx:Logger.Info", []string{"stdio"}, []string{"stdio"}},
+		{".:fx:warn", []string{"stdio"}, []string{"stdio"}},
+		{".:fx:parseFile", []string{"fs"}, []string{"fs"}},
+		{".:fx:parseSource", nil, nil},
+		{".:fx:run", nil, []string{"fs", "stdio"}},
+		{".:fx:upper", nil, nil},
+		{".:fx:save", []string{"fs"}, []string{"fs"}},
+		{".:fx:fetch", []string{"network"}, []string{"network"}},
+		{".:fx:build", nil, nil},
+		{".:fx:inspect", nil, nil},
+		{".:fx:start", []string{"process"}, []string{"process"}},
+	}
+	for _, test := range tests {
+		node := nodeByKey(t, analysis, test.key)
+		if !reflect.DeepEqual(node.SideEffects, test.direct) {
+			t.Errorf("%s has direct side effects %v, want %v", test.key, node.SideEffects, test.direct)
+		}
+		if len(node.Effects) == 0 && len(test.effects) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(node.Effects, test.effects) {
+			t.Errorf("%s has side effects %v, want %v", test.key, node.Effects, test.effects)
+		}
+	}
+
+	for _, symbol := range buildJSONReport(analysis.Nodes, "example.com/fx").Symbols {
+		if symbol.Key == ".:fx:run" && !reflect.DeepEqual(symbol.Effects, []string{"fs", "stdio"}) {
+			t.Errorf("JSON effects of run are %v", symbol.Effects)
+		}
+	}
+}
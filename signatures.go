@@ -0,0 +1,106 @@
+package main
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+	"unicode"
+)
+
+// funcSignature returns the declaration line of a function or method, including its
+// receiver, type parameters, parameters and results. Short signatures leave out the names of
+// the receiver, parameters and results, but not of type parameters, which the types refer to.
+func funcSignature(funcDecl *ast.FuncDecl, short bool) string {
+	var signature strings.Builder
+	signature.WriteString("func ")
+
+	if funcDecl.Recv != nil {
+		signature.WriteString("(" + fieldListString(funcDecl.Recv, short) + ") ")
+	}
+
+	signature.WriteString(funcDecl.Name.Name)
+	if funcDecl.Type.TypeParams != nil {
+		signature.WriteString("[" + fieldListString(funcDecl.Type.TypeParams, false) + "]")
+	}
+
+	signature.WriteString("(" + fieldListString(funcDecl.Type.Params, short) + ")")
+
+	// A single unnamed result is written without parentheses
+	if results := funcDecl.Type.Results; results != nil && len(results.List) > 0 {
+		single := len(results.List) == 1 && len(results.List[0].Names) <= 1
+		if single && (short || len(results.List[0].Names) == 0) {
+			signature.WriteString(" " + types.ExprString(results.List[0].Type))
+		} else {
+			signature.WriteString(" (" + fieldListString(results, short) + ")")
+		}
+	}
+
+	return signature.String()
+}
+
+// fieldListString formats a parameter, result or type parameter list as it appears in source.
+// In short form only the types are kept, once per name.
+func fieldListString(list *ast.FieldList, short bool) string {
+	if list == nil {
+		return ""
+	}
+
+	var parts []string
+	for _, field := range list.List {
+		fieldType := types.ExprString(field.Type)
+		switch {
+		case len(field.Names) == 0:
+			parts = append(parts, fieldType)
+		case short:
+			for range field.Names {
+				parts = append(parts, fieldType)
+			}
+		default:
+			var names []string
+			for _, name := range field.Names {
+				names = append(names, name.Name)
+			}
+			parts = append(parts, strings.Join(names, ", ")+" "+fieldType)
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// firstSentence returns the first sentence of a doc comment, on a single line. Like go/doc a
+// sentence ends at a period followed by a space, but not after abbreviations such as "e.g.",
+// "U.S." or "etc." or when the next word starts with a lower case letter.
+func firstSentence(doc string) string {
+	paragraph, _, _ := strings.Cut(strings.TrimSpace(doc), "\n\n")
+	text := strings.Join(strings.Fields(paragraph), " ")
+
+	for offset := 0; ; {
+		end := strings.Index(text[offset:], ". ")
+		if end < 0 {
+			return text
+		}
+		end += offset
+		if !isAbbreviation(text[strings.LastIndex(text[:end], " ")+1:end]) && !unicode.IsLower(rune(text[end+2])) {
+			return text[:end+1]
+		}
+		offset = end + 2
+	}
+}
+
+// isAbbreviation reports whether a word followed by a period is an abbreviation rather than
+// the end of a sentence: a single capital letter, a word with periods in it such as "e.g",
+// or a common abbreviation
+func isAbbreviation(word string) bool {
+	if len(word) == 1 && word[0] >= 'A' && word[0] <= 'Z' {
+		return true
+	}
+	word = strings.TrimLeft(word, "(")
+	if strings.Contains(word, ".") && strings.Trim(word, ".abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ") == "" {
+		return true
+	}
+	switch strings.ToLower(word) {
+	case "etc", "vs", "cf", "approx", "incl":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func TestFuncSignature(t *testing.T) {
+	tests := []struct {
+		source, full, short string
+	}{
+		{"func Run(ctx context.Context, addr string) error", "func Run(ctx context.Context, addr string) error", "func Run(context.Context, string) error"},
+		{"func Split(s string) (head, tail string)", "func Split(s string) (head, tail string)", "func Split(string) (string, string)"},
+		{"func (s *Server) Close() (err error)", "func (s *Server) Close() (err error)", "func (*Server) Close() error"},
+		{"func Keys[K comparable, V any](m map[K]V) []K", "func Keys[K comparable, V any](m map[K]V) []K", "func Keys[K comparable, V any](map[K]V) []K"},
+		{"func (m *Map[K, V]) Get(key K) (V, bool)", "func (m *Map[K, V]) Get(key K) (V, bool)", "func (*Map[K, V]) Get(K) (V, bool)"},
+	}
+	for _, test := range tests {
+		file, err := parser.ParseFile(token.NewFileSet(), "", "package p\n"+test.source+" { panic(0) }", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		funcDecl := file.Decls[0].(*ast.FuncDecl)
+		if got := funcSignature(funcDecl, false); got != test.full {
+			t.Errorf("funcSignature(%q) = %q, want %q", test.source, got, test.full)
+		}
+		if got := funcSignature(funcDecl, true); got != test.short {
+			t.Errorf("short funcSignature(%q) = %q, want %q", test.source, got, test.short)
+		}
+	}
+}
+
+func TestFirstSentence(t *testing.T) {
+	tests := []struct {
+		doc, want string
+	}{
+		{"Run starts the server.", "Run starts the server."},
+		{"Run starts the server. It blocks.", "Run starts the server."},
+		{"Run starts\nthe server.\nIt blocks.", "Run starts the server."},
+		{"Key returns the key, e.g. \"dir:pkg:Name\". Keys are unique.", "Key returns the key, e.g. \"dir:pkg:Name\"."},
+		{"Parse reads a value (i.e. a literal) from src. It fails on EOF.", "Parse reads a value (i.e. a literal) from src."},
+		{"Law follows U.S. rules. Other text.", "Law follows U.S. rules."},
+		{"Types such as maps, slices etc. are skipped. Others are kept.", "Types such as maps, slices etc. are skipped."},
+		{"Version 1.2. Next.", "Version 1.2."},
+		{"First paragraph\n\nSecond paragraph. More.", "First paragraph"},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if got := firstSentence(test.doc); got != test.want {
+			t.Errorf("firstSentence(%q) = %q, want %q", test.doc, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"html"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// setPosition records where a declaration starts and ends in its source file
+func setPosition(node *CodeNode, fset *token.FileSet, decl ast.Node) {
+	start := fset.Position(decl.Pos())
+	end := fset.Position(decl.End())
+	node.Line = start.Line
+	node.Column = start.Column
+	node.EndLine = end.Line
+}
+
+// sourceLocation returns the "path/to/file.go:123" location of a node
+func sourceLocation(node *CodeNode) string {
+	location := filepath.ToSlash(node.FilePath)
+	if node.Line > 0 {
+		location += fmt.Sprintf(":%d", node.Line)
+	}
+	return location
+}
+
+// positionLink returns the "  file.go:123" annotation shown after a symbol in the code tree,
+// as an HTML link to its source since the tree is a <pre> block rather than markdown
+func (o *Options) positionLink(node *CodeNode) string {
+	if node.Line == 0 {
+		return ""
+	}
+	return fmt.Sprintf(`  <a href="%s">%s:%d</a>`, html.EscapeString(o.sourceURL(node)), filepath.Base(node.FilePath), node.Line)
+}
+
+// sourceLink returns a markdown link to the node's source location
+func (o *Options) sourceLink(node *CodeNode) string {
+	return fmt.Sprintf("[%s](%s)", sourceLocation(node), o.sourceURL(node))
+}
+
+// sourceURL returns the link target for a node's source. With a link template the
+// placeholders {repo}, {module}, {rev}, {path}, {line} and {endline} are filled in,
+// otherwise the link is a path relative to the report file for local browsing. When
+// the line is unknown the link goes to the file, without a line fragment.
+func (o *Options) sourceURL(node *CodeNode) string {
+	target := filepath.Join(o.RepoPath, node.FilePath)
+
+	if o.LinkTemplate != "" {
+		// Paths are relative to the repository root, or to -path when no git repository was found
+		path := node.FilePath
+		if o.GitRoot != "" {
+			if absTarget, err := filepath.Abs(target); err == nil {
+				if relTarget, err := filepath.Rel(o.GitRoot, absTarget); err == nil {
+					path = relTarget
+				}
+			}
+		}
+
+		template := o.LinkTemplate
+		if fragment := strings.Index(template, "#"); node.Line == 0 && fragment >= 0 && strings.Contains(template[fragment:], "line}") {
+			template = template[:fragment]
+		}
+		replacer := strings.NewReplacer(
+			"{repo}", o.repositoryName(),
+			"{module}", o.ModulePath,
+			"{rev}", o.Revision,
+			"{path}", filepath.ToSlash(path),
+			"{line}", fmt.Sprint(node.Line),
+			"{endline}", fmt.Sprint(node.EndLine),
+		)
+		return replacer.Replace(template)
+	}
+
+	if absTarget, err := filepath.Abs(target); err == nil {
+		if absOutputDir, err := filepath.Abs(filepath.Dir(o.OutputFile)); err == nil {
+			if relTarget, err := filepath.Rel(absOutputDir, absTarget); err == nil {
+				target = relTarget
+			}
+		}
+	}
+
+	url := filepath.ToSlash(target)
+	if node.Line > 0 {
+		url += fmt.Sprintf("#L%d", node.Line)
+	}
+	return url
+}
+
+// repositoryName returns the "owner/name" of the repository, taken from the module path
+// when it starts with a host name, otherwise the name of the repository directory. The
+// module's directory within the git repository and a /vN major version suffix are left out.
+func (o *Options) repositoryName() string {
+	if host, repository, found := strings.Cut(o.ModulePath, "/"); found && strings.Contains(host, ".") {
+		if i := strings.LastIndex(repository, "/"); i >= 0 && isMajorVersion(repository[i+1:]) {
+			repository = repository[:i]
+		}
+		if subdirectory := o.moduleSubdirectory(); subdirectory != "" {
+			repository = strings.TrimSuffix(repository, "/"+subdirectory)
+		}
+		return repository
+	}
+	if o.ModulePath != "" {
+		return o.ModulePath
+	}
+
+	absRepoPath, err := filepath.Abs(o.RepoPath)
+	if err != nil {
+		return filepath.Base(o.RepoPath)
+	}
+	return filepath.Base(absRepoPath)
+}
+
+// moduleSubdirectory returns the directory of the analysed module within its git repository,
+// "" when it is the repository root or no git repository was found
+func (o *Options) moduleSubdirectory() string {
+	if o.GitRoot == "" {
+		return ""
+	}
+	absRepoPath, err := filepath.Abs(o.RepoPath)
+	if err != nil {
+		return ""
+	}
+	subdirectory, err := filepath.Rel(o.GitRoot, absRepoPath)
+	if err != nil || subdirectory == "." || strings.HasPrefix(subdirectory, "..") {
+		return ""
+	}
+	return filepath.ToSlash(subdirectory)
+}
+
+// isMajorVersion reports whether a module path element is a major version suffix such as v2
+func isMajorVersion(element string) bool {
+	digits, found := strings.CutPrefix(element, "v")
+	if !found || digits == "" || digits == "0" || digits == "1" || digits[0] == '0' {
+		return false
+	}
+	for _, digit := range digits {
+		if digit < '0' || digit > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// findGitRoot walks up from path to the directory containing the .git directory or file
+func findGitRoot(path string) (string, error) {
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("no .git directory found above %s", path)
+		}
+		dir = parent
+	}
+}
+
+// detectRevision reads the commit checked out in a git repository from its .git directory
+func detectRevision(gitRoot string) (string, error) {
+	gitDir := filepath.Join(gitRoot, ".git")
+
+	// Worktrees and submodules have a .git file pointing at the real git directory
+	if info, err := os.Stat(gitDir); err == nil && !info.IsDir() {
+		data, err := os.ReadFile(gitDir)
+		if err != nil {
+			return "", err
+		}
+		gitDir = strings.TrimSpace(strings.TrimPrefix(string(data), "gitdir:"))
+		if !filepath.IsAbs(gitDir) {
+			gitDir = filepath.Join(gitRoot, gitDir)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return "", err
+	}
+	head := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(head, "ref: ") {
+		return head, nil // Detached HEAD
+	}
+	ref := strings.TrimPrefix(head, "ref: ")
+
+	// Linked worktrees keep shared refs in the common directory
+	dirs := []string{gitDir}
+	if data, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		commonDir := strings.TrimSpace(string(data))
+		if !filepath.IsAbs(commonDir) {
+			commonDir = filepath.Join(gitDir, commonDir)
+		}
+		dirs = append(dirs, commonDir)
+	}
+
+	for _, dir := range dirs {
+		if data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(ref))); err == nil {
+			return strings.TrimSpace(string(data)), nil
+		}
+
+		// Refs may have been packed into a single file
+		if data, err := os.ReadFile(filepath.Join(dir, "packed-refs")); err == nil {
+			for _, line := range strings.Split(string(data), "\n") {
+				fields := strings.Fields(line)
+				if len(fields) == 2 && fields[1] == ref {
+					return fields[0], nil
+				}
+			}
+		}
+	}
+
+	return "", fmt.Errorf("cannot resolve %s", ref)
+}
+
+// functionLength returns the number of lines a function or method spans
+func functionLength(node *CodeNode) int {
+	if node.Line == 0 || node.EndLine < node.Line {
+		return 0
+	}
+	return node.EndLine - node.Line + 1
+}
+
+// addFunctionLengthsToOutput adds the average function length and the longest functions to the report
+func (a *Analysis) addFunctionLengthsToOutput(output *strings.Builder) {
+	const limit = 10
+
+	// Closures are left out, their lines already count towards the enclosing function
+	var functions []*CodeNode
+	for _, node := range selectGraphNodes(a.Nodes, "", 0) {
+		if node.Type != "closure" {
+			functions = append(functions, node)
+		}
+	}
+	if len(functions) == 0 {
+		return
+	}
+
+	totalLines := 0
+	for _, node := range functions {
+		totalLines += functionLength(node)
+	}
+	averageLength := float64(totalLines) / float64(len(functions))
+
+	sort.SliceStable(functions, func(i, j int) bool {
+		return functionLength(functions[i]) > functionLength(functions[j])
+	})
+	if len(functions) > limit {
+		functions = functions[:limit]
+	}
+
+	output.WriteString("\n## Longest Functions\n\n")
+	output.WriteString(fmt.Sprintf("*Average function length: %.1f lines*\n\n", averageLength))
+	output.WriteString("| Function | Location | Lines |\n")
+	output.WriteString("|----------|----------|------:|\n")
+
+	for _, node := range functions {
+		output.WriteString(fmt.Sprintf("| %s | %s | %d |\n", graphLabel(node), a.sourceLink(node), functionLength(node)))
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRepositoryName(t *testing.T) {
+	gitRoot := t.TempDir()
+	tests := []struct {
+		modulePath, repoPath, gitRoot, want string
+	}{
+		{"github.com/o/r", gitRoot, gitRoot, "o/r"},
+		{"github.com/o/r/v2", gitRoot, gitRoot, "o/r"},
+		{"github.com/o/r/v10", gitRoot, "", "o/r"},
+		{"github.com/o/r/tools", filepath.Join(gitRoot, "tools"), gitRoot, "o/r"},
+		{"github.com/o/r/tools/v3", filepath.Join(gitRoot, "tools"), gitRoot, "o/r"},
+		{"github.com/o/r/v2", filepath.Join(gitRoot, "v2"), gitRoot, "o/r"},
+		{"github.com/o/v1", gitRoot, gitRoot, "o/v1"},
+		{"gitlab.com/group/sub/r", gitRoot, gitRoot, "group/sub/r"},
+		{"example", gitRoot, gitRoot, "example"},
+	}
+	for _, test := range tests {
+		options := Options{ModulePath: test.modulePath, RepoPath: test.repoPath, GitRoot: test.gitRoot}
+		if got := options.repositoryName(); got != test.want {
+			t.Errorf("repositoryName() for %s in %s = %q, want %q", test.modulePath, test.repoPath, got, test.want)
+		}
+	}
+}
+
+func TestSourceURLWithoutGitRoot(t *testing.T) {
+	options := Options{
+		RepoPath:     t.TempDir(),
+		ModulePath:   "github.com/o/r",
+		LinkTemplate: "https://github.com/{repo}/blob/main/{path}#L{line}",
+	}
+	node := &CodeNode{FilePath: filepath.Join("cmd", "main.go"), Line: 7}
+	if got, want := options.sourceURL(node), "https://github.com/o/r/blob/main/cmd/main.go#L7"; got != want {
+		t.Errorf("sourceURL() = %q, want %q", got, want)
+	}
+}
+
+func TestCodeTreeLinksSymbols(t *testing.T) {
+	analysis := analyzeSource(t, map[string]string{"fx.go": `package fx
+
+// Drain empties a channel
+func Drain(ch <-chan int) {
+	for range ch {
+	}
+}
+`})
+
+	var output strings.Builder
+	analysis.renderCodeNode(&output, analysis.CodeRoot, "", true)
+	want := `func Drain(ch &lt;-chan int)  <a href="fx.go#L4">fx.go:4</a>  // Drain empties a channel`
+	if !strings.Contains(output.String(), want) {
+		t.Errorf("code tree does not contain %q\n%s", want, output.String())
+	}
+}
+
+func TestSourceURLWithoutLine(t *testing.T) {
+	dir := t.TempDir()
+	node := &CodeNode{FilePath: "main.go"}
+	tests := []struct {
+		template, want string
+	}{
+		{"https://github.com/{repo}/blob/main/{path}#L{line}", "https://github.com/o/r/blob/main/main.go"},
+		{"https://gitlab.com/{repo}/-/blob/main/{path}#L{line}-{endline}", "https://gitlab.com/o/r/-/blob/main/main.go"},
+		{"", "main.go"},
+	}
+	for _, test := range tests {
+		options := Options{RepoPath: dir, OutputFile: filepath.Join(dir, "report.md"), ModulePath: "github.com/o/r", LinkTemplate: test.template}
+		if got := options.sourceURL(node); got != test.want {
+			t.Errorf("sourceURL() with %q = %q, want %q", test.template, got, test.want)
+		}
+	}
+}
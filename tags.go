@@ -0,0 +1,414 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// nameTagKeys are the tag keys whose value starts with the field's wire name, followed by options
+var nameTagKeys = map[string]bool{
+	"json": true, "yaml": true, "xml": true, "toml": true, "db": true, "bson": true,
+	"mapstructure": true, "form": true, "msgpack": true,
+}
+
+// StructTag is one key:"value" pair from a struct field tag
+type StructTag struct {
+	Key     string
+	Name    string // Wire name for name tag keys, the whole value otherwise
+	Options []string
+}
+
+// parseStructTag splits a raw struct tag into its key:"value" pairs, following the
+// conventions of reflect.StructTag
+func parseStructTag(tag string) []StructTag {
+	var tags []StructTag
+
+	for tag != "" {
+		tag = strings.TrimLeft(tag, " ")
+		colon := strings.Index(tag, ":")
+		if colon <= 0 || colon+1 >= len(tag) || tag[colon+1] != '"' {
+			break
+		}
+		key := tag[:colon]
+		tag = tag[colon+1:]
+
+		// Find the closing quote, skipping escaped characters
+		i := 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			break
+		}
+		value, err := strconv.Unquote(tag[:i+1])
+		tag = tag[i+1:]
+		if err != nil {
+			continue
+		}
+
+		parsed := StructTag{Key: key, Name: value}
+		if nameTagKeys[key] {
+			parts := strings.Split(value, ",")
+			parsed.Name = parts[0]
+			parsed.Options = parts[1:]
+		}
+		tags = append(tags, parsed)
+	}
+
+	return tags
+}
+
+// structNodes returns the struct nodes of the module, sorted by key
+func structNodes(nodes map[string]*CodeNode) []*CodeNode {
+	var structs []*CodeNode
+	for _, node := range nodes {
+		if node.Type == "struct" {
+			structs = append(structs, node)
+		}
+	}
+	sortNodesByKey(structs)
+	return structs
+}
+
+// addStructTagsToOutput adds a table per tag key listing every tagged field, followed
+// by inconsistencies such as duplicate wire names and exported fields missing a tag
+func (a *Analysis) addStructTagsToOutput(output *strings.Builder) {
+	type taggedField struct {
+		structNode *CodeNode
+		field      *FieldInfo
+		tag        StructTag
+	}
+
+	byKey := make(map[string][]taggedField)
+	var issues [][3]string // struct, field, issue
+
+	for _, structNode := range structNodes(a.Nodes) {
+		keysUsed := make(map[string]bool)
+		wireNames := make(map[string]map[string]string) // key -> wire name -> field
+		fieldTags := make(map[*FieldInfo]map[string]bool)
+
+		for _, field := range structNode.Fields {
+			fieldTags[field] = make(map[string]bool)
+			for _, tag := range parseStructTag(field.Tag) {
+				byKey[tag.Key] = append(byKey[tag.Key], taggedField{structNode, field, tag})
+				keysUsed[tag.Key] = true
+				fieldTags[field][tag.Key] = true
+
+				if !field.Exported && !field.Embedded && nameTagKeys[tag.Key] {
+					issues = append(issues, [3]string{structNode.Name, field.Name,
+						fmt.Sprintf("`%s` tag on unexported field is ignored", tag.Key)})
+				}
+				if !nameTagKeys[tag.Key] || tag.Name == "" || tag.Name == "-" {
+					continue
+				}
+				if wireNames[tag.Key] == nil {
+					wireNames[tag.Key] = make(map[string]string)
+				}
+				if other, exists := wireNames[tag.Key][tag.Name]; exists {
+					issues = append(issues, [3]string{structNode.Name, field.Name,
+						fmt.Sprintf("duplicate `%s` name %q, also used by %s", tag.Key, tag.Name, other)})
+				} else {
+					wireNames[tag.Key][tag.Name] = field.Name
+				}
+			}
+		}
+
+		// Exported fields missing a name tag the rest of the struct uses
+		var keys []string
+		for key := range keysUsed {
+			if nameTagKeys[key] {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, field := range structNode.Fields {
+			if !field.Exported || field.Embedded {
+				continue
+			}
+			for _, key := range keys {
+				if !fieldTags[field][key] {
+					issues = append(issues, [3]string{structNode.Name, field.Name,
+						fmt.Sprintf("missing `%s` tag", key)})
+				}
+			}
+		}
+	}
+
+	if len(byKey) == 0 {
+		return
+	}
+
+	output.WriteString("\n## Struct Tags\n")
+
+	var keys []string
+	for key := range byKey {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		output.WriteString(fmt.Sprintf("\n### `%s`\n\n", key))
+		if nameTagKeys[key] {
+			output.WriteString("| Struct | Field | Go Type | Name | Options |\n")
+			output.WriteString("|--------|-------|---------|------|---------|\n")
+		} else {
+			output.WriteString("| Struct | Field | Go Type | Value |\n")
+			output.WriteString("|--------|-------|---------|-------|\n")
+		}
+
+		for _, tagged := range byKey[key] {
+			if nameTagKeys[key] {
+				output.WriteString(fmt.Sprintf("| %s | %s | `%s` | `%s` | %s |\n",
+					tagged.structNode.Name, tagged.field.Name, tagged.field.Type, tagged.tag.Name, strings.Join(tagged.tag.Options, ", ")))
+			} else {
+				output.WriteString(fmt.Sprintf("| %s | %s | `%s` | `%s` |\n",
+					tagged.structNode.Name, tagged.field.Name, tagged.field.Type, tagged.tag.Name))
+			}
+		}
+	}
+
+	if len(issues) > 0 {
+		output.WriteString("\n### Tag Issues\n\n")
+		output.WriteString("| Struct | Field | Issue |\n")
+		output.WriteString("|--------|-------|-------|\n")
+		for _, issue := range issues {
+			output.WriteString(fmt.Sprintf("| %s | %s | %s |\n", issue[0], issue[1], issue[2]))
+		}
+	}
+}
+
+// writeJSONSchemas writes a JSON Schema document for each named struct to the schema directory.
+// Names may be plain struct names or package-qualified, e.g. "Config" or "config.Config".
+func writeJSONSchemas(nodes map[string]*CodeNode, names []string, schemaDir string) error {
+	if err := os.MkdirAll(schemaDir, 0755); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		structNode := findStruct(nodes, name, "")
+		if structNode == nil {
+			return fmt.Errorf("struct %s not found", name)
+		}
+
+		defs := make(map[string]any)
+		schema := structSchema(nodes, structNode, defs)
+		schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+		schema["title"] = structNode.Name
+		if structNode.Doc != "" {
+			schema["description"] = structNode.Doc
+		}
+		if len(defs) > 0 {
+			schema["$defs"] = defs
+		}
+
+		data, err := json.MarshalIndent(schema, "", "  ")
+		if err != nil {
+			return err
+		}
+		schemaPath := filepath.Join(schemaDir, structNode.Name+".schema.json")
+		if err := os.WriteFile(schemaPath, append(data, '\n'), 0644); err != nil {
+			return err
+		}
+		log.Info("JSON Schema for %s saved to %s", structNode.Name, schemaPath)
+	}
+
+	return nil
+}
+
+// findStruct looks up a struct by name, preferring the given package, or by "package.Name"
+func findStruct(nodes map[string]*CodeNode, name string, packageKey string) *CodeNode {
+	if structNode, exists := nodes[packageKey+":"+name]; exists && structNode.Type == "struct" {
+		return structNode
+	}
+
+	packageName, typeName, qualified := strings.Cut(name, ".")
+	for _, structNode := range structNodes(nodes) {
+		if qualified {
+			key := packageKeyOf(structNode)
+			if structNode.Name == typeName && key[strings.LastIndex(key, ":")+1:] == packageName {
+				return structNode
+			}
+		} else if structNode.Name == name {
+			return structNode
+		}
+	}
+	return nil
+}
+
+// schemaProperty is the schema of a struct field's property, with where the field was found
+type schemaProperty struct {
+	schema   map[string]any
+	required bool
+	depth    int  // 0 for the struct's own fields, 1 for fields promoted from a struct it embeds, and so on
+	tagged   bool // Named by a json tag
+}
+
+// structSchema builds the object schema of a struct from its fields and json tags,
+// adding the structs it references to defs
+func structSchema(nodes map[string]*CodeNode, structNode *CodeNode, defs map[string]any) map[string]any {
+	properties := make(map[string]any)
+	var required []string
+	for name, property := range structProperties(nodes, structNode, defs, map[*CodeNode]bool{structNode: true}) {
+		properties[name] = property.schema
+		if property.required {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+// structProperties returns the properties of a struct's fields, including those promoted from
+// untagged embedded structs, by name. As in encoding/json a name used by several fields belongs
+// to the shallowest one, or at equal depth to the only one named by a tag, and otherwise to none,
+// so shadowed fields are neither properties nor required. Structs already being promoted are
+// skipped, so types embedding themselves, directly or through other structs, end too.
+func structProperties(nodes map[string]*CodeNode, structNode *CodeNode, defs map[string]any, promoting map[*CodeNode]bool) map[string]schemaProperty {
+	candidates := make(map[string][]schemaProperty)
+	for _, field := range structNode.Fields {
+		name := field.Name
+		omitEmpty, tagged := false, false
+		for _, tag := range parseStructTag(field.Tag) {
+			if tag.Key != "json" {
+				continue
+			}
+			if tag.Name != "" {
+				name, tagged = tag.Name, true
+			}
+			for _, option := range tag.Options {
+				omitEmpty = omitEmpty || option == "omitempty" || option == "omitzero"
+			}
+		}
+		if name == "-" || (!field.Exported && !field.Embedded) {
+			continue
+		}
+
+		// Untagged embedded structs have their fields promoted, as encoding/json does
+		if field.Embedded && !tagged {
+			typeName, _ := referencedTypeName(field.Type)
+			if embedded := findStruct(nodes, typeName, packageKeyOf(structNode)); embedded != nil {
+				if !promoting[embedded] {
+					promoting[embedded] = true
+					for promotedName, property := range structProperties(nodes, embedded, defs, promoting) {
+						property.depth++
+						candidates[promotedName] = append(candidates[promotedName], property)
+					}
+					delete(promoting, embedded)
+				}
+				continue
+			}
+		}
+
+		candidates[name] = append(candidates[name], schemaProperty{
+			schema:   typeSchema(nodes, field.Type, packageKeyOf(structNode), defs),
+			required: !omitEmpty,
+			tagged:   tagged,
+		})
+	}
+
+	properties := make(map[string]schemaProperty)
+	for name, fields := range candidates {
+		if property, ok := dominantProperty(fields); ok {
+			properties[name] = property
+		}
+	}
+	return properties
+}
+
+// dominantProperty returns the property encoding/json uses among fields sharing a name, or
+// false when the name is ambiguous and no field gets it
+func dominantProperty(fields []schemaProperty) (schemaProperty, bool) {
+	depth := fields[0].depth
+	for _, field := range fields {
+		depth = min(depth, field.depth)
+	}
+
+	var shallowest, tagged []schemaProperty
+	for _, field := range fields {
+		if field.depth != depth {
+			continue
+		}
+		shallowest = append(shallowest, field)
+		if field.tagged {
+			tagged = append(tagged, field)
+		}
+	}
+	if len(shallowest) == 1 {
+		return shallowest[0], true
+	}
+	if len(tagged) == 1 {
+		return tagged[0], true
+	}
+	return schemaProperty{}, false
+}
+
+// defName returns the package-qualified name a struct is stored under in $defs, e.g.
+// "models.Address", so structs of the same name from different packages do not collide
+func defName(structNode *CodeNode) string {
+	packageKey := packageKeyOf(structNode)
+	return packageKey[strings.LastIndex(packageKey, ":")+1:] + "." + structNode.Name
+}
+
+// typeSchema maps a Go type onto a JSON Schema, referencing structs of the module through defs
+func typeSchema(nodes map[string]*CodeNode, typeString string, packageKey string, defs map[string]any) map[string]any {
+	typeString = strings.TrimPrefix(typeString, "*")
+
+	switch typeString {
+	case "string":
+		return map[string]any{"type": "string"}
+	case "bool":
+		return map[string]any{"type": "boolean"}
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "uintptr", "byte", "rune", "time.Duration":
+		return map[string]any{"type": "integer"}
+	case "float32", "float64", "json.Number":
+		return map[string]any{"type": "number"}
+	case "time.Time":
+		return map[string]any{"type": "string", "format": "date-time"}
+	case "[]byte", "json.RawMessage":
+		if typeString == "[]byte" {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]any{}
+	case "any", "interface{}":
+		return map[string]any{}
+	}
+
+	if strings.HasPrefix(typeString, "[]") {
+		return map[string]any{"type": "array", "items": typeSchema(nodes, typeString[2:], packageKey, defs)}
+	}
+	if strings.HasPrefix(typeString, "[") {
+		if end := strings.Index(typeString, "]"); end > 0 {
+			return map[string]any{"type": "array", "items": typeSchema(nodes, typeString[end+1:], packageKey, defs)}
+		}
+	}
+	if _, valueType, isMap := splitMapType(typeString); isMap {
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(nodes, valueType, packageKey, defs)}
+	}
+
+	if structNode := findStruct(nodes, typeString, packageKey); structNode != nil {
+		name := defName(structNode)
+		if _, exists := defs[name]; !exists {
+			defs[name] = map[string]any{} // Placeholder, stops recursive types looping
+			defs[name] = structSchema(nodes, structNode, defs)
+		}
+		return map[string]any{"$ref": "#/$defs/" + name}
+	}
+
+	return map[string]any{}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestStructSchemaEmbedding(t *testing.T) {
+	analysis := analyzeSource(t, map[string]string{"fx.go": `package fx
+
+type Node struct {
+	*Node
+	Name string ` + "`json:\"name\"`" + `
+}
+
+type A struct {
+	*B
+	X int
+}
+
+type B struct {
+	*A
+	Y int ` + "`json:\"y,omitempty\"`" + `
+}
+
+type Base struct {
+	ID   string
+	Name string
+}
+
+type User struct {
+	Name string ` + "`json:\"name\"`" + `
+	Base
+}
+
+type Account struct {
+	Base
+	ID string ` + "`json:\"ID,omitempty\"`" + `
+}
+
+type Audit struct {
+	Created string
+	Name    string
+}
+
+type Record struct {
+	Base
+	Audit
+}
+
+type Tagged struct {
+	Base
+	Label string ` + "`json:\"Name\"`" + `
+}
+
+type Nested struct {
+	Record
+	Audit
+}
+`})
+
+	tests := []struct {
+		name       string
+		properties []string
+		required   []string
+	}{
+		{"Node", []string{"name"}, []string{"name"}},
+		{"A", []string{"X", "y"}, []string{"X"}},
+		{"B", []string{"X", "y"}, []string{"X"}},
+		{"User", []string{"ID", "Name", "name"}, []string{"ID", "Name", "name"}},
+		{"Account", []string{"ID", "Name"}, []string{"Name"}},            // ID is shadowed by the optional field declared after Base
+		{"Record", []string{"Created", "ID"}, []string{"Created", "ID"}}, // Name is ambiguous between Base and Audit
+		{"Tagged", []string{"ID", "Name"}, []string{"ID", "Name"}},
+		{"Nested", []string{"Created", "ID", "Name"}, []string{"Created", "ID", "Name"}}, // Audit is shallower than Record's fields
+	}
+	for _, test := range tests {
+		schema := structSchema(analysis.Nodes, nodeByKey(t, analysis, ".:fx:"+test.name), make(map[string]any))
+
+		var properties []string
+		for property := range schema["properties"].(map[string]any) {
+			properties = append(properties, property)
+		}
+		sort.Strings(properties)
+		if !reflect.DeepEqual(properties, test.properties) {
+			t.Errorf("%s properties = %v, want %v", test.name, properties, test.properties)
+		}
+		if required, _ := schema["required"].([]string); !reflect.DeepEqual(required, test.required) {
+			t.Errorf("%s required = %v, want %v", test.name, required, test.required)
+		}
+	}
+}
+
+func TestParseStructTag(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want []StructTag
+	}{
+		{`json:"name"`, []StructTag{{Key: "json", Name: "name", Options: []string{}}}},
+		{`json:"name,omitempty" yaml:"n"`, []StructTag{
+			{Key: "json", Name: "name", Options: []string{"omitempty"}},
+			{Key: "yaml", Name: "n", Options: []string{}},
+		}},
+		{`json:",string"`, []StructTag{{Key: "json", Name: "", Options: []string{"string"}}}},
+		{`json:"-"`, []StructTag{{Key: "json", Name: "-", Options: []string{}}}},
+		{`validate:"required,min=1"`, []StructTag{{Key: "validate", Name: "required,min=1"}}},
+		{`doc:"a \"quoted\" value"  db:"id"`, []StructTag{
+			{Key: "doc", Name: `a "quoted" value`},
+			{Key: "db", Name: "id", Options: []string{}},
+		}},
+		{`json:name`, nil},
+		{`json:"unterminated`, nil},
+		{``, nil},
+	}
+	for _, test := range tests {
+		if got := parseStructTag(test.tag); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("parseStructTag(%q) = %#v, want %#v", test.tag, got, test.want)
+		}
+	}
+}
+
+func TestTypeSchema(t *testing.T) {
+	analysis := analyzeSource(t, map[string]string{"fx.go": `package fx
+
+import "time"
+
+type Address struct {
+	City string
+}
+
+type Person struct {
+	Name     string
+	Age      int               ` + "`json:\"age,omitempty\"`" + `
+	Created  time.Time
+	Tags     []string
+	Scores   map[string]float64
+	Home     *Address
+	Previous []Address
+	Secret   string ` + "`json:\"-\"`" + `
+	internal bool
+}
+`})
+
+	defs := make(map[string]any)
+	schema := structSchema(analysis.Nodes, nodeByKey(t, analysis, ".:fx:Person"), defs)
+	want := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"Name":     map[string]any{"type": "string"},
+			"age":      map[string]any{"type": "integer"},
+			"Created":  map[string]any{"type": "string", "format": "date-time"},
+			"Tags":     map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			"Scores":   map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "number"}},
+			"Home":     map[string]any{"$ref": "#/$defs/fx.Address"},
+			"Previous": map[string]any{"type": "array", "items": map[string]any{"$ref": "#/$defs/fx.Address"}},
+		},
+		"required": []string{"Created", "Home", "Name", "Previous", "Scores", "Tags"},
+	}
+	if !reflect.DeepEqual(schema, want) {
+		t.Errorf("schema = %#v, want %#v", schema, want)
+	}
+
+	address := map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"City": map[string]any{"type": "string"}},
+		"required":   []string{"City"},
+	}
+	if !reflect.DeepEqual(defs, map[string]any{"fx.Address": address}) {
+		t.Errorf("defs = %#v", defs)
+	}
+}
+
+func TestJSONSchemaDefsArePackageQualified(t *testing.T) {
+	analysis := analyzeSource(t, map[string]string{
+		"billing/billing.go": `package billing
+
+type Address struct {
+	Street string
+}
+`,
+		"shipping/shipping.go": `package shipping
+
+import "example.com/fx/billing"
+
+type Address struct {
+	Port string
+}
+
+type Order struct {
+	Billing  billing.Address
+	Shipping Address
+}
+`,
+	})
+
+	defs := make(map[string]any)
+	schema := structSchema(analysis.Nodes, nodeByKey(t, analysis, "shipping:shipping:Order"), defs)
+	properties := schema["properties"].(map[string]any)
+	if got := properties["Billing"]; !reflect.DeepEqual(got, map[string]any{"$ref": "#/$defs/billing.Address"}) {
+		t.Errorf("Billing = %v", got)
+	}
+	if got := properties["Shipping"]; !reflect.DeepEqual(got, map[string]any{"$ref": "#/$defs/shipping.Address"}) {
+		t.Errorf("Shipping = %v", got)
+	}
+	if len(defs) != 2 {
+		t.Errorf("defs = %v, want billing.Address and shipping.Address", defs)
+	}
+}
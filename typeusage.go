@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"strings"
+)
+
+// TypeUsage lists the functions using a type, by the way they use it
+type TypeUsage struct {
+	Params     []*CodeNode // Take the type as a parameter
+	Results    []*CodeNode // Return the type
+	Constructs []*CodeNode // Build the type with a composite literal
+	References []*CodeNode // Refer to the type anywhere else in their body
+}
+
+// isTypeNode reports whether a node is a struct, interface or other named type
+func isTypeNode(node *CodeNode) bool {
+	return node.Type == "struct" || node.Type == "interface" || node.Type == "type"
+}
+
+// resolveTypeExpr returns the type node an identifier or package-qualified name refers to, or nil
+func resolveTypeExpr(nodes map[string]*CodeNode, expr ast.Expr, packageKey string, importMap map[string]string) *CodeNode {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		// Local variables, parameters and functions that shadow a type name are not types
+		if t.Obj != nil && t.Obj.Kind != ast.Typ {
+			return nil
+		}
+	case *ast.SelectorExpr:
+		x, ok := t.X.(*ast.Ident)
+		if !ok || x.Obj != nil {
+			return nil
+		}
+		if _, imported := importMap[x.Name]; !imported {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	if node, exists := nodes[resolveExpr(expr, packageKey, importMap)]; exists && isTypeNode(node) {
+		return node
+	}
+	return nil
+}
+
+// typeUsage returns the usage record of a type node, creating it on first use
+func typeUsage(typeNode *CodeNode) *TypeUsage {
+	if typeNode.Usage == nil {
+		typeNode.Usage = &TypeUsage{}
+	}
+	return typeNode.Usage
+}
+
+// addUsage adds a function to a usage list once
+func addUsage(list *[]*CodeNode, function *CodeNode) {
+	if !containsNode(*list, function) {
+		*list = append(*list, function)
+	}
+}
+
+// recordSignatureTypes records the types a function takes as parameters and returns
+func (a *Analysis) recordSignatureTypes(funcDecl *ast.FuncDecl, function *CodeNode, packageKey string, importMap map[string]string) {
+	record := func(fields *ast.FieldList, usage func(*TypeUsage) *[]*CodeNode) {
+		if fields == nil {
+			return
+		}
+		for _, field := range fields.List {
+			ast.Inspect(field.Type, func(n ast.Node) bool {
+				expr, ok := n.(ast.Expr)
+				if !ok {
+					return true
+				}
+				if typeNode := resolveTypeExpr(a.Nodes, expr, packageKey, importMap); typeNode != nil {
+					addUsage(usage(typeUsage(typeNode)), function)
+					return false
+				}
+				return true
+			})
+		}
+	}
+
+	record(funcDecl.Type.Params, func(usage *TypeUsage) *[]*CodeNode { return &usage.Params })
+	record(funcDecl.Type.Results, func(usage *TypeUsage) *[]*CodeNode { return &usage.Results })
+}
+
+// recordBodyTypeUsage records a type constructed or referenced by an expression in a function body.
+// Field names in selectors and composite literal keys are added to skip so they are not mistaken for types.
+func (a *Analysis) recordBodyTypeUsage(n ast.Node, function *CodeNode, packageKey string, importMap map[string]string, skip map[ast.Node]bool) {
+	switch node := n.(type) {
+	case *ast.CompositeLit:
+		if node.Type == nil {
+			return
+		}
+		literalType := node.Type
+		if index, ok := literalType.(*ast.IndexExpr); ok {
+			literalType = index.X
+		} else if index, ok := literalType.(*ast.IndexListExpr); ok {
+			literalType = index.X
+		}
+		if typeNode := resolveTypeExpr(a.Nodes, literalType, packageKey, importMap); typeNode != nil {
+			addUsage(&typeUsage(typeNode).Constructs, function)
+			skip[literalType] = true
+			if selector, ok := literalType.(*ast.SelectorExpr); ok {
+				skip[selector.Sel] = true
+			}
+		}
+		for _, element := range node.Elts {
+			if keyValue, ok := element.(*ast.KeyValueExpr); ok {
+				if key, ok := keyValue.Key.(*ast.Ident); ok {
+					skip[key] = true
+				}
+			}
+		}
+
+	case *ast.SelectorExpr:
+		if skip[node] {
+			return
+		}
+		skip[node.Sel] = true
+		if typeNode := resolveTypeExpr(a.Nodes, node, packageKey, importMap); typeNode != nil {
+			addUsage(&typeUsage(typeNode).References, function)
+		}
+
+	case *ast.Ident:
+		if skip[node] {
+			return
+		}
+		if typeNode := resolveTypeExpr(a.Nodes, node, packageKey, importMap); typeNode != nil {
+			addUsage(&typeUsage(typeNode).References, function)
+		}
+	}
+}
+
+// addTypeUsageToOutput adds the cross-reference of where each type is used to the report
+func (a *Analysis) addTypeUsageToOutput(output *strings.Builder) {
+	var typeNodes []*CodeNode
+	for _, node := range a.Nodes {
+		if isTypeNode(node) {
+			typeNodes = append(typeNodes, node)
+		}
+	}
+	if len(typeNodes) == 0 {
+		return
+	}
+	sortNodesByKey(typeNodes)
+
+	usageList := func(functions []*CodeNode) string {
+		if len(functions) == 0 {
+			return "-"
+		}
+		var names []string
+		for _, function := range functions {
+			names = append(names, "`"+graphLabel(function)+"`")
+		}
+		return strings.Join(names, ", ")
+	}
+
+	output.WriteString("\n## Type Usage\n\n")
+	output.WriteString("| Type | Location | Parameter Of | Returned By | Constructed In | Referenced In |\n")
+	output.WriteString("|------|----------|--------------|-------------|----------------|---------------|\n")
+
+	for _, typeNode := range typeNodes {
+		usage := typeUsage(typeNode)
+		output.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s | %s |\n",
+			typeNode.Name, a.sourceLink(typeNode), usageList(usage.Params), usageList(usage.Results),
+			usageList(usage.Constructs), usageList(usage.References)))
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// processValues creates nodes for the constants or variables declared in a const or var block.
+// Constants of an iota block with a named type are marked as values of that enum type.
+func processValues(genDecl *ast.GenDecl, relPath string, fset *token.FileSet) []*CodeNode {
+	var valueNodes []*CodeNode
+
+	// Constants without values repeat the type and expressions of the previous spec
+	var carriedType string
+	var carriedValues []ast.Expr
+	iotaTypes := make(map[string]bool) // Types of the constants whose values, written or repeated, use iota
+
+	for _, spec := range genDecl.Specs {
+		valueSpec, ok := spec.(*ast.ValueSpec)
+		if !ok {
+			continue
+		}
+
+		valueType := ""
+		if valueSpec.Type != nil {
+			valueType = types.ExprString(valueSpec.Type)
+		}
+		values := valueSpec.Values
+
+		if genDecl.Tok == token.CONST {
+			if len(values) == 0 {
+				valueType = carriedType
+				values = carriedValues
+			} else {
+				carriedType = valueType
+				carriedValues = values
+			}
+			for _, value := range values {
+				if referencesIota(value) {
+					iotaTypes[valueType] = true
+				}
+			}
+		}
+
+		doc := valueSpec.Doc
+		if doc == nil && len(genDecl.Specs) == 1 {
+			doc = genDecl.Doc
+		}
+
+		for i, name := range valueSpec.Names {
+			if name.Name == "_" {
+				continue
+			}
+
+			valueNode := &CodeNode{
+				Name:      name.Name,
+				Type:      "variable",
+				FilePath:  relPath,
+				Doc:       firstSentence(doc.Text()),
+				ValueType: valueType,
+			}
+			if genDecl.Tok == token.CONST {
+				valueNode.Type = "constant"
+			}
+			if i < len(valueSpec.Values) {
+				valueNode.Value = types.ExprString(valueSpec.Values[i])
+			}
+			setPosition(valueNode, fset, name)
+			valueNode.EndLine = fset.Position(valueSpec.End()).Line
+
+			valueNodes = append(valueNodes, valueNode)
+		}
+	}
+
+	// Enum values are the constants typed with a named local type that is given iota values in the block
+	for _, valueNode := range valueNodes {
+		if iotaTypes[valueNode.ValueType] && token.IsIdentifier(valueNode.ValueType) && types.Universe.Lookup(valueNode.ValueType) == nil {
+			valueNode.EnumType = valueNode.ValueType
+		}
+	}
+
+	return valueNodes
+}
+
+// referencesIota reports whether an expression uses iota
+func referencesIota(expr ast.Expr) bool {
+	found := false
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok && ident.Name == "iota" {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// groupEnumValues moves the values of enum types in a package under their type
+func groupEnumValues(packageNode *CodeNode) {
+	typeNodes := make(map[string]*CodeNode)
+	for _, child := range packageNode.Children {
+		if child.Type == "type" || child.Type == "struct" {
+			typeNodes[child.Name] = child
+		}
+	}
+
+	var children []*CodeNode
+	for _, child := range packageNode.Children {
+		if typeNode, exists := typeNodes[child.EnumType]; exists && child.Type == "constant" {
+			typeNode.Children = append(typeNode.Children, child)
+			continue
+		}
+		children = append(children, child)
+	}
+	packageNode.Children = children
+}
+
+// enumValues returns the enum constants nested under a type node
+func enumValues(typeNode *CodeNode) []*CodeNode {
+	var values []*CodeNode
+	for _, child := range typeNode.Children {
+		if child.Type == "constant" && child.EnumType == typeNode.Name {
+			values = append(values, child)
+		}
+	}
+	return values
+}
+
+// addEnumsToOutput adds the enum types, their values and whether they have a String method to the report
+func (a *Analysis) addEnumsToOutput(output *strings.Builder) {
+	var enumTypes []*CodeNode
+	for _, node := range a.Nodes {
+		if (node.Type == "type" || node.Type == "struct") && len(enumValues(node)) > 0 {
+			enumTypes = append(enumTypes, node)
+		}
+	}
+	if len(enumTypes) == 0 {
+		return
+	}
+	sortNodesByKey(enumTypes)
+
+	output.WriteString("\n## Enums\n\n")
+	output.WriteString("| Type | Package | Values | String() | Location |\n")
+	output.WriteString("|------|---------|--------|----------|----------|\n")
+
+	for _, typeNode := range enumTypes {
+		var names []string
+		for _, value := range enumValues(typeNode) {
+			names = append(names, "`"+value.Name+"`")
+		}
+
+		hasString := "no"
+		if methodNames(typeNode)["String"] {
+			hasString = "yes"
+		}
+
+		output.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s |\n",
+			typeNode.Name, packageKeyOf(typeNode), strings.Join(names, ", "), hasString, a.sourceLink(typeNode)))
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+func TestEnumValues(t *testing.T) {
+	analysis := analyzeSource(t, map[string]string{"fx.go": `package fx
+
+type Color int
+type Size int
+type Mode string
+
+const (
+	Red Color = iota
+	Green
+	Blue
+
+	Small Size = 10
+	Large
+
+	Unknown Color = 99
+	Fast    Mode  = "fast"
+)
+
+const (
+	KB Size = 1 << (10 * (iota + 1))
+	MB
+)
+
+const (
+	first = iota
+	Plain Mode = "plain"
+)
+`})
+
+	tests := map[string]string{
+		"Red": "Color", "Green": "Color", "Blue": "Color", "Unknown": "Color",
+		"Small": "", "Large": "", "Fast": "",
+		"KB": "Size", "MB": "Size",
+		"first": "", "Plain": "",
+	}
+	for name, want := range tests {
+		if got := nodeByKey(t, analysis, ".:fx:"+name).EnumType; got != want {
+			t.Errorf("EnumType of %s = %q, want %q", name, got, want)
+		}
+	}
+}